@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+)
+
+// ArchiveList holds the archived projects shown in the archive view
+type ArchiveList struct {
+	projects []models.Project
+	cursor   int
+}
+
+func (m *model) updateArchiveList() {
+	m.archiveList.projects = m.storage.GetArchivedProjects()
+	if m.archiveList.cursor >= len(m.archiveList.projects) {
+		m.archiveList.cursor = len(m.archiveList.projects) - 1
+	}
+	if m.archiveList.cursor < 0 {
+		m.archiveList.cursor = 0
+	}
+}
+
+// updateArchive handles keys in the archive view
+func (m model) updateArchive(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "A":
+		m.activeView = "projects"
+		m.refreshData()
+	case "up":
+		m.archiveList.cursor--
+		if m.archiveList.cursor < 0 {
+			m.archiveList.cursor = len(m.archiveList.projects) - 1
+		}
+	case "down":
+		m.archiveList.cursor++
+		if m.archiveList.cursor >= len(m.archiveList.projects) {
+			m.archiveList.cursor = 0
+		}
+	case "a":
+		if len(m.archiveList.projects) == 0 {
+			return m, nil
+		}
+		cmd = m.execute(&history.SetProjectArchived{Project: m.archiveList.projects[m.archiveList.cursor], Archived: false},
+			"Project unarchived")
+		m.updateArchiveList()
+	}
+
+	return m, cmd
+}
+
+func (m model) renderArchive() string {
+	s := "Archive (A: unarchive, U/Ctrl+R: undo/redo, ↑/↓: select, Shift+L: messages, ESC: back, Q: quit)\n\n"
+
+	if len(m.archiveList.projects) == 0 {
+		return s + "No archived projects\n"
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	rowStyle := lipgloss.NewStyle()
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	row := "%-24.24s %-18.18s %12s  %-10s  %-10s  %-10s"
+	s += headerStyle.Render(fmt.Sprintf(row, "Project", "Client", "Cost", "Deadline", "Status", "Archived")) + "\n"
+
+	var total models.Money
+	for i, p := range m.archiveList.projects {
+		style := rowStyle
+		if i == m.archiveList.cursor {
+			style = selectedStyle
+		}
+		archived := ""
+		if p.ArchivedAt != nil {
+			archived = p.ArchivedAt.Format("2006-01-02")
+		}
+		s += style.Render(fmt.Sprintf(row, p.Name, p.Client, formatPrice(p), p.Deadline, p.Status, archived)) + "\n"
+		total += m.income(p, time.Now())
+	}
+
+	s += fmt.Sprintf("\n%d archived projects, %s earned\n", len(m.archiveList.projects), m.formatReporting(total))
+	return s + m.rateWarning()
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+	"freelancy.go/ui"
+)
+
+// ClientList holds the clients shown in the clients view with the totals of
+// their projects, sorted by one of the columns
+type ClientList struct {
+	clients    []models.ClientSummary
+	cursor     int
+	sortColumn int
+	descending bool
+}
+
+// clientColumns are the columns of the client list; each can be sorted by
+var clientColumns = []struct {
+	title string
+	less  func(a, b models.ClientSummary) bool
+}{
+	{"Client", func(a, b models.ClientSummary) bool {
+		return strings.ToLower(a.Client.Name) < strings.ToLower(b.Client.Name)
+	}},
+	{"Billed", func(a, b models.ClientSummary) bool { return a.Billed < b.Billed }},
+	{"Paid", func(a, b models.ClientSummary) bool { return a.Paid < b.Paid }},
+	{"Outstanding", func(a, b models.ClientSummary) bool { return a.Outstanding < b.Outstanding }},
+	{"Projects", func(a, b models.ClientSummary) bool { return a.Projects < b.Projects }},
+	{"Avg value", func(a, b models.ClientSummary) bool { return a.AverageValue < b.AverageValue }},
+	{"Days to pay", func(a, b models.ClientSummary) bool { return a.DaysToPay < b.DaysToPay }},
+	{"Hours", func(a, b models.ClientSummary) bool { return a.Hours < b.Hours }},
+}
+
+// clientRow lays out a row of the client list, matching clientColumns
+const clientRow = "%-24.24s %12s %12s %12s %9s %12s %12s %8s"
+
+// updateClientList reloads the clients and their totals, keeping the
+// selected client under the cursor
+func (m *model) updateClientList() {
+	selectedID := 0
+	if m.clientList.cursor < len(m.clientList.clients) {
+		selectedID = m.clientList.clients[m.clientList.cursor].Client.ID
+	}
+
+	ledger := models.NewLedger(m.storage.GetInvoices(), m.storage.GetPayments())
+	m.clientList.clients = ledger.Summarize(m.storage.GetClients(), m.allProjects(), time.Now(), m.reporting)
+	m.sortClientList()
+
+	for i, c := range m.clientList.clients {
+		if c.Client.ID == selectedID {
+			m.clientList.cursor = i
+		}
+	}
+	if m.clientList.cursor >= len(m.clientList.clients) {
+		m.clientList.cursor = len(m.clientList.clients) - 1
+	}
+	if m.clientList.cursor < 0 {
+		m.clientList.cursor = 0
+	}
+}
+
+// sortClientList orders the clients by the sort column, breaking ties by name
+func (m *model) sortClientList() {
+	list := &m.clientList
+	less := clientColumns[list.sortColumn].less
+	byName := clientColumns[0].less
+	sort.SliceStable(list.clients, func(i, j int) bool {
+		a, b := list.clients[i], list.clients[j]
+		if list.descending {
+			a, b = b, a
+		}
+		if less(a, b) != less(b, a) {
+			return less(a, b)
+		}
+		return byName(list.clients[i], list.clients[j])
+	})
+}
+
+// updateClients handles keys in the clients view
+func (m model) updateClients(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "C":
+		m.activeView = "projects"
+		m.refreshData()
+	case "left", "right":
+		// Names sort A to Z first, amounts largest first
+		step := 1
+		if msg.String() == "left" {
+			step = len(clientColumns) - 1
+		}
+		m.clientList.sortColumn = (m.clientList.sortColumn + step) % len(clientColumns)
+		m.clientList.descending = m.clientList.sortColumn != 0
+		m.updateClientList()
+	case "r":
+		m.clientList.descending = !m.clientList.descending
+		m.updateClientList()
+	case "up":
+		m.clientList.cursor--
+		if m.clientList.cursor < 0 {
+			m.clientList.cursor = len(m.clientList.clients) - 1
+		}
+	case "down":
+		m.clientList.cursor++
+		if m.clientList.cursor >= len(m.clientList.clients) {
+			m.clientList.cursor = 0
+		}
+	case "n":
+		m.clientForm = ui.NewClientForm()
+		m.activeView = "new_client"
+		return m, m.clientForm.Init()
+	case "e":
+		if len(m.clientList.clients) == 0 {
+			return m, nil
+		}
+		m.editingClient = m.clientList.clients[m.clientList.cursor].Client
+		m.clientForm = ui.EditClientForm(toUIClients([]models.Client{m.editingClient})[0])
+		m.activeView = "edit_client"
+		return m, m.clientForm.Init()
+	case "d":
+		if len(m.clientList.clients) == 0 {
+			return m, nil
+		}
+		client := m.clientList.clients[m.clientList.cursor].Client
+		if projects := m.clientProjects(client.ID); len(projects) > 0 {
+			return m, m.status.Error(fmt.Sprintf("'%s' still has %d projects, move them to another client first",
+				client.Name, len(projects)))
+		}
+		m.confirm(fmt.Sprintf("Delete client '%s'?", client.Name), &history.DeleteClient{Client: client}, "Client deleted")
+	}
+	return m, nil
+}
+
+// updateClientForm handles the form of the new_client and edit_client views
+func (m model) updateClientForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" {
+		m.activeView = "clients"
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.clientForm, cmd = m.clientForm.Update(msg)
+	if !m.clientForm.Done() {
+		return m, cmd
+	}
+
+	values := m.clientForm.GetValues()
+	client := models.Client{
+		Name:     values.Name,
+		Email:    values.Email,
+		Phone:    values.Phone,
+		Address:  values.Address,
+		Currency: values.Currency,
+		Rate:     models.Cents(values.Rate),
+		Notes:    values.Notes,
+	}
+	if m.activeView == "edit_client" {
+		client.ID = m.editingClient.ID
+		cmd = m.execute(&history.UpdateClient{Client: m.editingClient, Updated: client}, "Client saved")
+	} else {
+		add := &history.AddClient{Client: client}
+		cmd = m.execute(add, "Client saved")
+		client.ID = add.Client.ID
+	}
+
+	m.activeView = "clients"
+	m.refreshData()
+	for i, c := range m.clientList.clients {
+		if c.Client.ID == client.ID {
+			m.clientList.cursor = i
+		}
+	}
+	return m, cmd
+}
+
+// clientProjects returns the projects done for a client, including those in
+// the archive and the trash
+func (m model) clientProjects(clientID int) []models.Project {
+	trashed, _ := m.storage.GetTrash()
+	var projects []models.Project
+	for _, p := range append(m.allProjects(), trashed...) {
+		if p.ClientID == clientID {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
+// uiClients returns the clients offered by the client picker of the project form
+func (m model) uiClients() []ui.Client {
+	return toUIClients(m.storage.GetClients())
+}
+
+// toUIClients converts stored clients into the UI layer representation
+func toUIClients(clients []models.Client) []ui.Client {
+	var uiClients []ui.Client
+	for _, c := range clients {
+		uiClients = append(uiClients, ui.Client{
+			ID:       c.ID,
+			Name:     c.Name,
+			Email:    c.Email,
+			Phone:    c.Phone,
+			Address:  c.Address,
+			Currency: c.Currency,
+			Rate:     c.Rate.Float(),
+			Notes:    c.Notes,
+		})
+	}
+	return uiClients
+}
+
+func (m model) renderClients() string {
+	s := "Clients (N: new, E: edit, D: delete, ←/→: sort column, R: reverse order, U/Ctrl+R: undo/redo, ↑/↓: select, Shift+L: messages, ESC: back, Q: quit)\n\n"
+
+	if len(m.clientList.clients) == 0 {
+		return s + "No clients yet\n"
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	rowStyle := lipgloss.NewStyle()
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	titles := make([]any, len(clientColumns))
+	for i, column := range clientColumns {
+		titles[i] = column.title
+		if i == m.clientList.sortColumn {
+			arrow := "▲"
+			if m.clientList.descending {
+				arrow = "▼"
+			}
+			titles[i] = arrow + column.title
+		}
+	}
+	s += headerStyle.Render(fmt.Sprintf(clientRow, titles...)) + "\n"
+
+	var total models.ClientSummary
+	var missing []string
+	for i, c := range m.clientList.clients {
+		style := rowStyle
+		if i == m.clientList.cursor {
+			style = selectedStyle
+		}
+		s += style.Render(m.formatClientRow(c.Client.Name, c)) + "\n"
+
+		total.Billed += c.Billed
+		total.Paid += c.Paid
+		total.Outstanding += c.Outstanding
+		total.Projects += c.Projects
+		total.AverageValue += c.AverageValue * models.Money(c.Projects)
+		total.Hours += c.Hours
+		for _, currency := range c.Missing {
+			if !slices.Contains(missing, currency) {
+				missing = append(missing, currency)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if total.Projects > 0 {
+		total.AverageValue /= models.Money(total.Projects)
+	}
+	total.DaysToPay = -1
+	s += headerStyle.Render(m.formatClientRow("Total", total)) + "\n" + m.missingWarning(missing)
+
+	summary := m.clientList.clients[m.clientList.cursor]
+	c := summary.Client
+	s += "\n" + headerStyle.Render(c.Name) + "\n"
+	details := []struct{ label, value string }{
+		{"Email", c.Email},
+		{"Phone", c.Phone},
+		{"Address", c.Address},
+		{"Currency", c.Currency},
+		{"Rate", ""},
+		{"Notes", c.Notes},
+	}
+	if c.Rate > 0 {
+		details[4].value = c.Rate.Format(c.Currency) + "/h"
+	}
+	for _, d := range details {
+		if d.value != "" {
+			s += fmt.Sprintf("  %s: %s\n", d.label, d.value)
+		}
+	}
+	var names []string
+	for _, p := range m.clientProjects(c.ID) {
+		switch {
+		case p.DeletedAt != nil:
+			names = append(names, p.Name+" (in trash)")
+		case p.ArchivedAt != nil:
+			names = append(names, p.Name+" (archived)")
+		default:
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) > 0 {
+		s += "  Projects: " + strings.Join(names, ", ") + "\n"
+	}
+	if len(summary.Missing) > 0 {
+		s += "  Totals leave out amounts in " + strings.Join(summary.Missing, ", ") + "\n"
+	}
+	return s
+}
+
+// formatClientRow lays out the totals of a client under the given name
+func (m model) formatClientRow(name string, c models.ClientSummary) string {
+	daysToPay := "-"
+	if c.DaysToPay >= 0 {
+		daysToPay = fmt.Sprintf("%.0f", c.DaysToPay)
+	}
+	return fmt.Sprintf(clientRow, name,
+		m.formatReporting(c.Billed),
+		m.formatReporting(c.Paid),
+		m.formatReporting(c.Outstanding),
+		fmt.Sprint(c.Projects),
+		m.formatReporting(c.AverageValue),
+		daysToPay,
+		fmt.Sprintf("%.1f", c.Hours),
+	)
+}
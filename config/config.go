@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"freelancy.go/internal/tax"
+)
+
+// Config holds user settings read from config.json in the data directory
+type Config struct {
+	// TrashRetentionDays is how long deleted projects and tasks stay in the
+	// trash before they are purged; 0 keeps them forever
+	TrashRetentionDays int `json:"trash_retention_days"`
+
+	// InvoiceDir is where rendered invoices are written by default
+	InvoiceDir string `json:"invoice_dir"`
+	// TaxRate is the default tax percentage added to new invoices
+	TaxRate float64 `json:"tax_rate"`
+	// PaymentTermDays is the default number of days between the issue and
+	// due dates of new invoices
+	PaymentTermDays int `json:"payment_term_days"`
+
+	// Currency is the reporting currency: the income chart and totals over
+	// several projects are converted into it with the rates in rates.txt. It
+	// is also the currency of new projects whose client has none
+	Currency string `json:"currency"`
+
+	// IncomeTax holds the rules the tax panel of the income view estimates
+	// the income tax to set aside with; VAT comes from the tax on invoices
+	IncomeTax tax.Rules `json:"income_tax"`
+
+	// ReportDir is where PDF reports such as the income report are written
+	ReportDir string `json:"report_dir"`
+	// Business is printed at the top of PDF invoices and reports
+	Business Business `json:"business"`
+}
+
+// Business holds the details of the freelancer sending invoices
+type Business struct {
+	Name    string `json:"name"`
+	Address string `json:"address"` // lines separated by \n
+	TaxID   string `json:"tax_id"`
+	// BankDetails tells clients how to pay, lines separated by \n
+	BankDetails string `json:"bank_details"`
+	// Logo is the path of a PNG or JPEG image, relative to the data directory
+	// unless absolute
+	Logo string `json:"logo"`
+}
+
+// Default returns the settings used when no config file exists
+func Default() Config {
+	return Config{
+		TrashRetentionDays: 30,
+		PaymentTermDays:    14,
+		Currency:           "USD",
+		IncomeTax:          tax.Rules{Brackets: map[string][]tax.Bracket{}},
+	}
+}
+
+// Load reads config.json from dataDir. A missing file is created with the
+// default settings so they can be edited by hand
+func Load(dataDir string) (Config, error) {
+	defaults := Default()
+	defaults.InvoiceDir = filepath.Join(dataDir, "invoices")
+	defaults.ReportDir = dataDir
+	cfg := defaults
+	path := filepath.Join(dataDir, "config.json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return cfg, err
+		}
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return cfg, err
+		}
+		return cfg, os.WriteFile(path, data, 0644)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaults, err
+	}
+	cfg.Currency = strings.ToUpper(strings.TrimSpace(cfg.Currency))
+	if cfg.Currency == "" {
+		cfg.Currency = defaults.Currency
+	}
+	if cfg.Business.Logo != "" && !filepath.IsAbs(cfg.Business.Logo) {
+		cfg.Business.Logo = filepath.Join(dataDir, cfg.Business.Logo)
+	}
+	if err := cfg.IncomeTax.Validate(); err != nil {
+		cfg.IncomeTax = defaults.IncomeTax
+		return cfg, fmt.Errorf("income_tax: %w", err)
+	}
+	return cfg, nil
+}
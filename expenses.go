@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+	"freelancy.go/ui"
+)
+
+// ExpenseList holds the expenses shown in the expenses view, oldest first
+type ExpenseList struct {
+	expenses []models.Expense
+	cursor   int
+}
+
+// updateExpenseList reloads the expenses, keeping the selected expense under
+// the cursor
+func (m *model) updateExpenseList() {
+	selectedID := 0
+	if m.expenseList.cursor < len(m.expenseList.expenses) {
+		selectedID = m.expenseList.expenses[m.expenseList.cursor].ID
+	}
+
+	m.expenseList.expenses = m.storage.GetExpenses()
+	for i, e := range m.expenseList.expenses {
+		if e.ID == selectedID {
+			m.expenseList.cursor = i
+		}
+	}
+	if m.expenseList.cursor >= len(m.expenseList.expenses) {
+		m.expenseList.cursor = len(m.expenseList.expenses) - 1
+	}
+	if m.expenseList.cursor < 0 {
+		m.expenseList.cursor = 0
+	}
+}
+
+// updateExpenses handles keys in the expenses view
+func (m model) updateExpenses(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "E":
+		m.activeView = "projects"
+		m.refreshData()
+	case "up":
+		m.expenseList.cursor--
+		if m.expenseList.cursor < 0 {
+			m.expenseList.cursor = len(m.expenseList.expenses) - 1
+		}
+	case "down":
+		m.expenseList.cursor++
+		if m.expenseList.cursor >= len(m.expenseList.expenses) {
+			m.expenseList.cursor = 0
+		}
+	case "n":
+		m.expenseForm = ui.NewExpenseForm(m.expenseProjects(), 0, time.Now().Format(ui.DateLayout), m.config.Currency)
+		m.activeView = "new_expense"
+		return m, m.expenseForm.Init()
+	case "e":
+		if len(m.expenseList.expenses) == 0 {
+			return m, nil
+		}
+		m.editingExpense = m.expenseList.expenses[m.expenseList.cursor]
+		m.expenseForm = ui.EditExpenseForm(toUIExpense(m.editingExpense), m.expenseProjects(), m.config.Currency)
+		m.activeView = "edit_expense"
+		return m, m.expenseForm.Init()
+	case "d":
+		if len(m.expenseList.expenses) == 0 {
+			return m, nil
+		}
+		expense := m.expenseList.expenses[m.expenseList.cursor]
+		m.confirm(fmt.Sprintf("Delete the %s expense of %s from %s?", expense.Category,
+			expense.Amount.Format(expense.Currency), expense.Date), &history.DeleteExpense{Expense: expense}, "Expense deleted")
+	}
+	return m, nil
+}
+
+// updateExpenseForm handles the form of the new_expense and edit_expense views
+func (m model) updateExpenseForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" {
+		m.activeView = "expenses"
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.expenseForm, cmd = m.expenseForm.Update(msg)
+	if !m.expenseForm.Done() {
+		return m, cmd
+	}
+
+	values := m.expenseForm.GetValues()
+	expense := models.Expense{
+		Date:      values.Date,
+		Amount:    models.Cents(values.Amount),
+		Currency:  values.Currency,
+		Category:  values.Category,
+		Note:      values.Note,
+		Receipt:   values.Receipt,
+		ProjectID: values.ProjectID,
+	}
+	if m.activeView == "edit_expense" {
+		expense.ID = m.editingExpense.ID
+		cmd = m.execute(&history.UpdateExpense{Expense: m.editingExpense, Updated: expense}, "Expense saved")
+	} else {
+		add := &history.AddExpense{Expense: expense}
+		cmd = m.execute(add, "Expense saved")
+		expense.ID = add.Expense.ID
+	}
+
+	m.activeView = "expenses"
+	m.refreshData()
+	for i, e := range m.expenseList.expenses {
+		if e.ID == expense.ID {
+			m.expenseList.cursor = i
+		}
+	}
+	return m, cmd
+}
+
+// expenseProjects returns the projects offered by the project picker of the
+// expense form, archived ones included
+func (m model) expenseProjects() []ui.Project {
+	return toUIProjects(m.allProjects())
+}
+
+// toUIExpense converts a stored expense into the UI layer representation
+func toUIExpense(e models.Expense) ui.Expense {
+	return ui.Expense{
+		ID:        e.ID,
+		Date:      e.Date,
+		Amount:    e.Amount.Float(),
+		Currency:  e.Currency,
+		Category:  e.Category,
+		Note:      e.Note,
+		Receipt:   e.Receipt,
+		ProjectID: e.ProjectID,
+	}
+}
+
+// uiExpenses converts expenses into the reporting currency at the rate of
+// their day, leaving out those without a rate
+func (m model) uiExpenses(expenses []models.Expense) []ui.Earning {
+	var converted []ui.Earning
+	for _, e := range expenses {
+		date, ok := e.Spent()
+		if !ok {
+			continue
+		}
+		if amount, ok := m.reporting(e.Amount, e.Currency, date); ok {
+			converted = append(converted, ui.Earning{Date: date, Amount: amount.Float()})
+		}
+	}
+	return converted
+}
+
+// projectNames returns the names of all projects by ID, including those in
+// the archive and the trash
+func (m model) projectNames() map[int]string {
+	trashed, _ := m.storage.GetTrash()
+	names := make(map[int]string)
+	for _, p := range append(m.allProjects(), trashed...) {
+		names[p.ID] = p.Name
+	}
+	return names
+}
+
+func (m model) renderExpenses() string {
+	s := "Expenses (N: new, E: edit, D: delete, U/Ctrl+R: undo/redo, ↑/↓: select, Shift+L: messages, ESC: back, Q: quit)\n\n"
+
+	if len(m.expenseList.expenses) == 0 {
+		return s + "No expenses yet\n"
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	rowStyle := lipgloss.NewStyle()
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	names := m.projectNames()
+	row := "%-10s  %-24.24s %-20.20s %12s  %s"
+	s += headerStyle.Render(fmt.Sprintf(row, "Date", "Project", "Category", "Amount", "Note")) + "\n"
+
+	var total models.Money
+	byCategory := make(map[string]models.Money)
+	for i, e := range m.expenseList.expenses {
+		style := rowStyle
+		if i == m.expenseList.cursor {
+			style = selectedStyle
+		}
+		project := "Business"
+		if e.ProjectID != 0 {
+			project = names[e.ProjectID]
+		}
+		s += style.Render(fmt.Sprintf(row, e.Date, project, e.Category, e.Amount.Format(e.Currency), e.Note)) + "\n"
+
+		if date, ok := e.Spent(); ok {
+			amount, _ := m.reporting(e.Amount, e.Currency, date)
+			total += amount
+			byCategory[e.Category] += amount
+		}
+	}
+	s += headerStyle.Render(fmt.Sprintf(row, "Total", "", "", m.formatReporting(total), "")) + "\n" + m.rateWarning()
+
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if byCategory[categories[i]] != byCategory[categories[j]] {
+			return byCategory[categories[i]] > byCategory[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	var parts []string
+	for _, category := range categories {
+		parts = append(parts, fmt.Sprintf("%s %s", category, m.formatReporting(byCategory[category])))
+	}
+	s += "By category: " + strings.Join(parts, ", ") + "\n"
+
+	if e := m.expenseList.expenses[m.expenseList.cursor]; e.Receipt != "" {
+		s += "\nReceipt: " + e.Receipt + "\n"
+	}
+	return s
+}
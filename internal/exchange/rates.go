@@ -0,0 +1,199 @@
+// Package exchange converts amounts between currencies with exchange rates
+// kept by hand in a text file
+package exchange
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+// FileName is the name of the rate table in the data directory
+const FileName = "rates.txt"
+
+// example is written to a new rate table to show its format
+const example = `# Exchange rates used to convert amounts into the reporting currency set in
+# config.json. Each line holds the day a rate applies from, two currency codes
+# and how much one unit of the first currency is worth in the second:
+#
+#   2026-01-01 EUR USD 1.08
+#
+# A rate applies until a later rate for the same currencies, and amounts dated
+# before the first rate use that first rate. Rates work both ways, and from
+# one currency to another through a third one when both have a rate to it.
+`
+
+// Rates is a table of exchange rates between pairs of currencies, with the
+// day each rate applies from
+type Rates struct {
+	pairs map[pair][]rate // oldest first
+}
+
+type pair struct {
+	from, to string
+}
+
+type rate struct {
+	day   string // YYYY-MM-DD
+	value float64
+}
+
+// Load reads the rate table from dataDir. A missing table is created with
+// an explanation of its format so it can be filled in by hand
+func Load(dataDir string) (Rates, error) {
+	path := filepath.Join(dataDir, FileName)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Rates{}, os.WriteFile(path, []byte(example), 0644)
+	}
+	if err != nil {
+		return Rates{}, err
+	}
+	defer f.Close()
+
+	rates, err := Parse(f)
+	if err != nil {
+		return rates, fmt.Errorf("%s: %w", FileName, err)
+	}
+	return rates, nil
+}
+
+// Parse reads a rate table. Blank lines and lines starting with # are
+// ignored; a malformed line is reported with its number, and the rates
+// read until then are kept
+func Parse(r io.Reader) (Rates, error) {
+	rates := Rates{pairs: make(map[pair][]rate)}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return rates, fmt.Errorf("line %d: expected a date, two currency codes and a rate", n)
+		}
+		if _, err := time.Parse("2006-01-02", fields[0]); err != nil {
+			return rates, fmt.Errorf("line %d: date must be YYYY-MM-DD", n)
+		}
+		from, to := strings.ToUpper(fields[1]), strings.ToUpper(fields[2])
+		if !isCode(from) || !isCode(to) || from == to {
+			return rates, fmt.Errorf("line %d: expected two different 3-letter currency codes", n)
+		}
+		value, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil || value <= 0 {
+			return rates, fmt.Errorf("line %d: rate must be a positive number", n)
+		}
+		key := pair{from, to}
+		rates.pairs[key] = append(rates.pairs[key], rate{day: fields[0], value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return rates, err
+	}
+
+	for _, list := range rates.pairs {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].day < list[j].day })
+	}
+	return rates, nil
+}
+
+// isCode reports whether s looks like an ISO 4217 currency code
+func isCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Rate returns how much one unit of from was worth in to on day (YYYY-MM-DD):
+// from a rate between the two currencies in either direction, or through a
+// third currency
+func (r Rates) Rate(from, to, day string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if value, ok := r.direct(from, to, day); ok {
+		return value, true
+	}
+	for _, via := range r.currencies() {
+		first, ok := r.direct(from, via, day)
+		if !ok {
+			continue
+		}
+		if second, ok := r.direct(via, to, day); ok {
+			return first * second, true
+		}
+	}
+	return 0, false
+}
+
+// direct returns the rate between two currencies on day when the table has
+// one in either direction
+func (r Rates) direct(from, to, day string) (float64, bool) {
+	if value, ok := at(r.pairs[pair{from, to}], day); ok {
+		return value, true
+	}
+	if value, ok := at(r.pairs[pair{to, from}], day); ok {
+		return 1 / value, true
+	}
+	return 0, false
+}
+
+// at returns the last of rates applying on day, or the first rate for days
+// before all of them
+func at(rates []rate, day string) (float64, bool) {
+	if len(rates) == 0 {
+		return 0, false
+	}
+	value := rates[0].value
+	for _, r := range rates {
+		if r.day > day {
+			break
+		}
+		value = r.value
+	}
+	return value, true
+}
+
+// currencies returns every currency that appears in the table, sorted
+func (r Rates) currencies() []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for p := range r.pairs {
+		for _, code := range []string{p.from, p.to} {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Convert converts an amount from one currency into another at the rate of
+// the day of date. It returns false when the table has no rate between them
+func (r Rates) Convert(amount models.Money, from, to string, date time.Time) (models.Money, bool) {
+	if from == "" {
+		from = models.DefaultCurrency
+	}
+	value, ok := r.Rate(from, to, date.Local().Format("2006-01-02"))
+	if !ok {
+		return 0, false
+	}
+	return amount.Times(value), true
+}
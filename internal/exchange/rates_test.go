@@ -0,0 +1,131 @@
+package exchange
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+const table = `# rates
+2026-01-01 EUR USD 1.10
+2026-02-01 eur usd 1.20
+
+2026-01-01 GBP EUR 1.25
+2026-01-01 USD JPY 150
+`
+
+func parse(t *testing.T, s string) Rates {
+	t.Helper()
+	rates, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rates
+}
+
+func day(s string) time.Time {
+	d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return d.Add(12 * time.Hour)
+}
+
+func TestConvert(t *testing.T) {
+	rates := parse(t, table)
+	tests := []struct {
+		name     string
+		amount   float64
+		from, to string
+		date     string
+		want     float64
+		ok       bool
+	}{
+		{"same currency", 12.34, "USD", "USD", "2026-01-15", 12.34, true},
+		{"no currency is the default", 10, "", "USD", "2026-01-15", 10, true},
+		{"direct", 100, "EUR", "USD", "2026-01-15", 110, true},
+		{"later rate", 100, "EUR", "USD", "2026-02-01", 120, true},
+		{"before the first rate", 100, "EUR", "USD", "2025-06-01", 110, true},
+		{"inverse", 120, "USD", "EUR", "2026-03-01", 100, true},
+		{"through a third currency", 100, "GBP", "USD", "2026-01-15", 137.50, true},
+		{"through a third currency inverse", 15000, "JPY", "EUR", "2026-01-15", 90.91, true},
+		{"rounded to cents", 0.01, "EUR", "USD", "2026-03-01", 0.01, true},
+		{"missing rate", 100, "CHF", "USD", "2026-01-15", 0, false},
+		{"missing rate to a listed currency", 100, "EUR", "CHF", "2026-01-15", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rates.Convert(models.Cents(tt.amount), tt.from, tt.to, day(tt.date))
+			if ok != tt.ok || got != models.Cents(tt.want) {
+				t.Errorf("Convert(%g %s to %s) = %s, %v, want %s, %v", tt.amount, tt.from, tt.to,
+					got.Format(tt.to), ok, models.Cents(tt.want).Format(tt.to), tt.ok)
+			}
+		})
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	rates := parse(t, table)
+	// Rounding to whole cents on the way there is off by up to half a cent,
+	// which is worth rate times as much on the way back, and the way back
+	// rounds once more
+	for _, cents := range []models.Money{1, 99, 1000, 123456, 99999999} {
+		for _, currencies := range [][2]string{{"EUR", "USD"}, {"USD", "EUR"}, {"GBP", "USD"}, {"JPY", "EUR"}} {
+			there, ok := rates.Convert(cents, currencies[0], currencies[1], day("2026-02-10"))
+			if !ok {
+				t.Fatalf("no rate from %s to %s", currencies[0], currencies[1])
+			}
+			back, _ := rates.Convert(there, currencies[1], currencies[0], day("2026-02-10"))
+			rate, _ := rates.Rate(currencies[1], currencies[0], "2026-02-10")
+			if diff := (back - cents).Float() * 100; diff > rate/2+1 || diff < -rate/2-1 {
+				t.Errorf("%d cents %s to %s and back is %d cents", cents, currencies[0], currencies[1], back)
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		err  string
+	}{
+		{"2026-01-01 EUR USD", "expected a date, two currency codes and a rate"},
+		{"01.01.2026 EUR USD 1.1", "date must be YYYY-MM-DD"},
+		{"2026-01-01 EURO USD 1.1", "expected two different 3-letter currency codes"},
+		{"2026-01-01 EUR EUR 1.1", "expected two different 3-letter currency codes"},
+		{"2026-01-01 EUR USD 0", "rate must be a positive number"},
+		{"2026-01-01 EUR USD abc", "rate must be a positive number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rates, err := Parse(strings.NewReader("2026-01-01 GBP USD 1.3\n\n" + tt.line + "\n"))
+			if err == nil || !strings.Contains(err.Error(), "line 3: "+tt.err) {
+				t.Fatalf("error %v, want line 3: %s", err, tt.err)
+			}
+			// The rates read before the bad line are kept
+			if _, ok := rates.Rate("GBP", "USD", "2026-01-01"); !ok {
+				t.Error("the rates before the bad line were dropped")
+			}
+		})
+	}
+}
+
+func TestLoadCreatesExample(t *testing.T) {
+	dir := t.TempDir()
+	rates, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rates.Convert(100, "EUR", "USD", time.Now()); ok {
+		t.Error("an empty table converted an amount")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil || string(data) != example {
+		t.Fatalf("example table %q (%v)", data, err)
+	}
+	// The example itself is a valid, empty table
+	if _, err := Load(dir); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,636 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/storage"
+)
+
+// Command is a reversible change to the repository
+type Command interface {
+	Do(repo storage.Repository) error
+	Undo(repo storage.Repository) error
+	Description() string
+}
+
+// History records executed commands so they can be undone and redone
+type History struct {
+	undo []Command
+	redo []Command
+}
+
+// Execute runs cmd and records it for undo; the redo stack is cleared
+func (h *History) Execute(repo storage.Repository, cmd Command) error {
+	if err := cmd.Do(repo); err != nil {
+		return err
+	}
+	h.undo = append(h.undo, cmd)
+	h.redo = nil
+	return nil
+}
+
+// Undo reverts the most recent command and returns it, or nil if there is nothing to undo
+func (h *History) Undo(repo storage.Repository) (Command, error) {
+	if len(h.undo) == 0 {
+		return nil, nil
+	}
+	cmd := h.undo[len(h.undo)-1]
+	if err := cmd.Undo(repo); err != nil {
+		return cmd, err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, cmd)
+	return cmd, nil
+}
+
+// Redo re-applies the most recently undone command and returns it, or nil if there is nothing to redo
+func (h *History) Redo(repo storage.Repository) (Command, error) {
+	if len(h.redo) == 0 {
+		return nil, nil
+	}
+	cmd := h.redo[len(h.redo)-1]
+	if err := cmd.Do(repo); err != nil {
+		return cmd, err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, cmd)
+	return cmd, nil
+}
+
+// AddProject creates a project; undoing it removes the project again
+type AddProject struct {
+	Project models.Project
+	created bool
+}
+
+func (c *AddProject) Do(repo storage.Repository) error {
+	if c.created {
+		return repo.InsertProject(c.Project)
+	}
+	id, err := repo.AddProject(c.Project)
+	if err != nil {
+		return err
+	}
+	c.Project.ID = id
+	c.created = true
+	return nil
+}
+
+func (c *AddProject) Undo(repo storage.Repository) error {
+	if p, ok := findProject(repo, c.Project.ID); ok {
+		c.Project = p
+	}
+	return repo.PurgeProject(c.Project.ID)
+}
+
+func (c *AddProject) Description() string {
+	return fmt.Sprintf("add project '%s'", c.Project.Name)
+}
+
+// AddTask creates a task; undoing it removes the task again
+type AddTask struct {
+	Task    models.Task
+	created bool
+}
+
+func (c *AddTask) Do(repo storage.Repository) error {
+	if c.created {
+		return repo.InsertTask(c.Task)
+	}
+	id, err := repo.AddTask(c.Task.ProjectID, c.Task)
+	if err != nil {
+		return err
+	}
+	if t, ok := findTask(repo, c.Task.ProjectID, id); ok {
+		c.Task = t
+	}
+	c.created = true
+	return nil
+}
+
+func (c *AddTask) Undo(repo storage.Repository) error {
+	if t, ok := findTask(repo, c.Task.ProjectID, c.Task.ID); ok {
+		c.Task = t
+	}
+	return repo.PurgeTask(c.Task.ProjectID, c.Task.ID)
+}
+
+func (c *AddTask) Description() string {
+	return fmt.Sprintf("add task '%s'", c.Task.Title)
+}
+
+// DeleteProject moves a project with its tasks to the trash, stopping any
+// timer running on them; undoing it restores them and restarts the timer
+type DeleteProject struct {
+	Project models.Project
+	stopped []*StopTimer
+}
+
+func (c *DeleteProject) Do(repo storage.Repository) error {
+	if p, ok := findProject(repo, c.Project.ID); ok {
+		c.Project = p
+	}
+	var err error
+	if c.stopped, err = stopTimers(repo, c.Project.Tasks); err != nil {
+		return err
+	}
+	return repo.DeleteProject(c.Project.ID)
+}
+
+func (c *DeleteProject) Undo(repo storage.Repository) error {
+	if err := repo.RestoreProject(c.Project.ID); err != nil {
+		return err
+	}
+	return restartTimers(repo, c.stopped)
+}
+
+func (c *DeleteProject) Description() string {
+	return fmt.Sprintf("delete project '%s'", c.Project.Name)
+}
+
+// DeleteTask moves a task to the trash, stopping its timer if it is running;
+// undoing it restores the task and restarts the timer
+type DeleteTask struct {
+	Task    models.Task
+	stopped []*StopTimer
+}
+
+func (c *DeleteTask) Do(repo storage.Repository) error {
+	if t, ok := findTask(repo, c.Task.ProjectID, c.Task.ID); ok {
+		c.Task = t
+	}
+	var err error
+	if c.stopped, err = stopTimers(repo, []models.Task{c.Task}); err != nil {
+		return err
+	}
+	return repo.DeleteTask(c.Task.ProjectID, c.Task.ID)
+}
+
+func (c *DeleteTask) Undo(repo storage.Repository) error {
+	if err := repo.RestoreTask(c.Task.ProjectID, c.Task.ID); err != nil {
+		return err
+	}
+	return restartTimers(repo, c.stopped)
+}
+
+func (c *DeleteTask) Description() string {
+	return fmt.Sprintf("delete task '%s'", c.Task.Title)
+}
+
+// RestoreProject takes a project out of the trash; undoing it trashes the project again
+type RestoreProject struct {
+	Project models.Project
+}
+
+func (c *RestoreProject) Do(repo storage.Repository) error {
+	return repo.RestoreProject(c.Project.ID)
+}
+
+func (c *RestoreProject) Undo(repo storage.Repository) error {
+	return repo.DeleteProject(c.Project.ID)
+}
+
+func (c *RestoreProject) Description() string {
+	return fmt.Sprintf("restore project '%s'", c.Project.Name)
+}
+
+// RestoreTask takes a task out of the trash; undoing it trashes the task again
+type RestoreTask struct {
+	Task models.Task
+}
+
+func (c *RestoreTask) Do(repo storage.Repository) error {
+	return repo.RestoreTask(c.Task.ProjectID, c.Task.ID)
+}
+
+func (c *RestoreTask) Undo(repo storage.Repository) error {
+	return repo.DeleteTask(c.Task.ProjectID, c.Task.ID)
+}
+
+func (c *RestoreTask) Description() string {
+	return fmt.Sprintf("restore task '%s'", c.Task.Title)
+}
+
+// PurgeProject permanently removes a trashed project; undoing it puts the
+// project back into the trash exactly as it was
+type PurgeProject struct {
+	Project models.Project
+}
+
+func (c *PurgeProject) Do(repo storage.Repository) error {
+	return repo.PurgeProject(c.Project.ID)
+}
+
+func (c *PurgeProject) Undo(repo storage.Repository) error {
+	return repo.InsertProject(c.Project)
+}
+
+func (c *PurgeProject) Description() string {
+	return fmt.Sprintf("purge project '%s'", c.Project.Name)
+}
+
+// PurgeTask permanently removes a trashed task; undoing it puts the task
+// back into the trash exactly as it was
+type PurgeTask struct {
+	Task models.Task
+}
+
+func (c *PurgeTask) Do(repo storage.Repository) error {
+	return repo.PurgeTask(c.Task.ProjectID, c.Task.ID)
+}
+
+func (c *PurgeTask) Undo(repo storage.Repository) error {
+	return repo.InsertTask(c.Task)
+}
+
+func (c *PurgeTask) Description() string {
+	return fmt.Sprintf("purge task '%s'", c.Task.Title)
+}
+
+// SetProjectStatus changes a project status; undoing it restores the previous status
+type SetProjectStatus struct {
+	Project   models.Project
+	NewStatus string
+}
+
+func (c *SetProjectStatus) Do(repo storage.Repository) error {
+	return repo.UpdateProjectStatus(c.Project.ID, c.NewStatus)
+}
+
+func (c *SetProjectStatus) Undo(repo storage.Repository) error {
+	return repo.UpdateProjectStatus(c.Project.ID, c.Project.Status)
+}
+
+func (c *SetProjectStatus) Description() string {
+	return fmt.Sprintf("mark project '%s' as %s", c.Project.Name, c.NewStatus)
+}
+
+// UpdateProject saves edited project details; undoing it restores the previous details
+type UpdateProject struct {
+	Project models.Project
+	Updated models.Project
+}
+
+func (c *UpdateProject) Do(repo storage.Repository) error {
+	return repo.UpdateProject(c.Updated)
+}
+
+func (c *UpdateProject) Undo(repo storage.Repository) error {
+	return repo.UpdateProject(c.Project)
+}
+
+func (c *UpdateProject) Description() string {
+	return fmt.Sprintf("edit project '%s'", c.Updated.Name)
+}
+
+// UpdateTask saves edited task details; undoing it restores the previous details
+type UpdateTask struct {
+	Task    models.Task
+	Updated models.Task
+}
+
+func (c *UpdateTask) Do(repo storage.Repository) error {
+	return repo.UpdateTask(c.Updated)
+}
+
+func (c *UpdateTask) Undo(repo storage.Repository) error {
+	return repo.UpdateTask(c.Task)
+}
+
+func (c *UpdateTask) Description() string {
+	return fmt.Sprintf("edit task '%s'", c.Updated.Title)
+}
+
+// SetProjectArchived moves a project into or out of the archive; undoing it
+// puts the project back where it was. Archiving a project stops any timer
+// running on its tasks, and undoing that restarts the timer
+type SetProjectArchived struct {
+	Project  models.Project
+	Archived bool
+	stopped  []*StopTimer
+}
+
+func (c *SetProjectArchived) Do(repo storage.Repository) error {
+	return c.setArchived(repo, c.Archived)
+}
+
+func (c *SetProjectArchived) Undo(repo storage.Repository) error {
+	return c.setArchived(repo, c.Project.ArchivedAt != nil)
+}
+
+func (c *SetProjectArchived) setArchived(repo storage.Repository, archived bool) error {
+	if !archived {
+		if err := repo.SetProjectArchived(c.Project.ID, false); err != nil {
+			return err
+		}
+		err := restartTimers(repo, c.stopped)
+		c.stopped = nil
+		return err
+	}
+	if p, ok := findProject(repo, c.Project.ID); ok {
+		var err error
+		if c.stopped, err = stopTimers(repo, p.Tasks); err != nil {
+			return err
+		}
+	}
+	return repo.SetProjectArchived(c.Project.ID, true)
+}
+
+func (c *SetProjectArchived) Description() string {
+	if c.Archived {
+		return fmt.Sprintf("archive project '%s'", c.Project.Name)
+	}
+	return fmt.Sprintf("unarchive project '%s'", c.Project.Name)
+}
+
+// SetTaskStatus moves a task to another kanban column; undoing it restores
+// the previous status and completion date
+type SetTaskStatus struct {
+	Task             models.Task
+	NewStatus        string
+	NewCompletedDate string
+}
+
+func (c *SetTaskStatus) Do(repo storage.Repository) error {
+	return repo.UpdateTaskStatus(c.Task.ProjectID, c.Task.ID, c.NewStatus, c.NewCompletedDate)
+}
+
+func (c *SetTaskStatus) Undo(repo storage.Repository) error {
+	return repo.UpdateTaskStatus(c.Task.ProjectID, c.Task.ID, c.Task.Status, c.Task.CompletedDate)
+}
+
+func (c *SetTaskStatus) Description() string {
+	return fmt.Sprintf("move task '%s' to %s", c.Task.Title, c.NewStatus)
+}
+
+// StartTimer starts timing work on a task; undoing it discards the time entry
+type StartTimer struct {
+	Task  models.Task
+	Entry models.TimeEntry
+}
+
+func (c *StartTimer) Do(repo storage.Repository) error {
+	id, err := repo.AddTimeEntry(c.Task.ProjectID, c.Task.ID, c.Entry)
+	if err != nil {
+		return err
+	}
+	c.Entry.ID = id
+	return nil
+}
+
+func (c *StartTimer) Undo(repo storage.Repository) error {
+	return repo.DeleteTimeEntry(c.Task.ProjectID, c.Task.ID, c.Entry.ID)
+}
+
+func (c *StartTimer) Description() string {
+	return fmt.Sprintf("start timer on '%s'", c.Task.Title)
+}
+
+// StopTimer stops the running timer of a task at End and attaches Note to the
+// time entry; undoing it lets the timer run again
+type StopTimer struct {
+	Task  models.Task
+	Entry models.TimeEntry
+	End   time.Time
+	Note  string
+}
+
+func (c *StopTimer) Do(repo storage.Repository) error {
+	stopped := c.Entry
+	stopped.End = &c.End
+	stopped.Note = c.Note
+	return repo.UpdateTimeEntry(c.Task.ProjectID, c.Task.ID, stopped)
+}
+
+func (c *StopTimer) Undo(repo storage.Repository) error {
+	return repo.UpdateTimeEntry(c.Task.ProjectID, c.Task.ID, c.Entry)
+}
+
+func (c *StopTimer) Description() string {
+	return fmt.Sprintf("stop timer on '%s'", c.Task.Title)
+}
+
+// AddInvoice issues an invoice and marks the work it bills as invoiced;
+// undoing it deletes the invoice and makes the work billable again
+type AddInvoice struct {
+	Invoice models.Invoice
+}
+
+func (c *AddInvoice) Do(repo storage.Repository) error {
+	id, err := repo.AddInvoice(c.Invoice)
+	if err != nil {
+		return err
+	}
+	for _, inv := range repo.GetInvoices() {
+		if inv.ID == id {
+			c.Invoice = inv
+		}
+	}
+	return nil
+}
+
+func (c *AddInvoice) Undo(repo storage.Repository) error {
+	return repo.DeleteInvoice(c.Invoice.ID)
+}
+
+func (c *AddInvoice) Description() string {
+	return fmt.Sprintf("issue invoice %s to '%s'", c.Invoice.Number, c.Invoice.Client)
+}
+
+// RecordPayment records a payment received for an invoice or a project;
+// undoing it removes the payment
+type RecordPayment struct {
+	Payment models.Payment
+	// Target names the invoice or project paid, and Currency the currency
+	// it is billed in, for messages
+	Target   string
+	Currency string
+}
+
+func (c *RecordPayment) Do(repo storage.Repository) error {
+	id, err := repo.AddPayment(c.Payment)
+	if err != nil {
+		return err
+	}
+	c.Payment.ID = id
+	return nil
+}
+
+func (c *RecordPayment) Undo(repo storage.Repository) error {
+	return repo.DeletePayment(c.Payment.ID)
+}
+
+func (c *RecordPayment) Description() string {
+	return fmt.Sprintf("record payment of %s for %s", c.Payment.Amount.Format(c.Currency), c.Target)
+}
+
+// AddClient creates a client; undoing it removes the client again
+type AddClient struct {
+	Client  models.Client
+	created bool
+}
+
+func (c *AddClient) Do(repo storage.Repository) error {
+	if c.created {
+		return repo.InsertClient(c.Client)
+	}
+	id, err := repo.AddClient(c.Client)
+	if err != nil {
+		return err
+	}
+	c.Client.ID = id
+	c.created = true
+	return nil
+}
+
+func (c *AddClient) Undo(repo storage.Repository) error {
+	return repo.DeleteClient(c.Client.ID)
+}
+
+func (c *AddClient) Description() string {
+	return fmt.Sprintf("add client '%s'", c.Client.Name)
+}
+
+// UpdateClient saves edited client details; undoing it restores the previous details
+type UpdateClient struct {
+	Client  models.Client
+	Updated models.Client
+}
+
+func (c *UpdateClient) Do(repo storage.Repository) error {
+	return repo.UpdateClient(c.Updated)
+}
+
+func (c *UpdateClient) Undo(repo storage.Repository) error {
+	return repo.UpdateClient(c.Client)
+}
+
+func (c *UpdateClient) Description() string {
+	return fmt.Sprintf("edit client '%s'", c.Updated.Name)
+}
+
+// DeleteClient removes a client without projects; undoing it puts the client back
+type DeleteClient struct {
+	Client models.Client
+}
+
+func (c *DeleteClient) Do(repo storage.Repository) error {
+	return repo.DeleteClient(c.Client.ID)
+}
+
+func (c *DeleteClient) Undo(repo storage.Repository) error {
+	return repo.InsertClient(c.Client)
+}
+
+func (c *DeleteClient) Description() string {
+	return fmt.Sprintf("delete client '%s'", c.Client.Name)
+}
+
+// AddExpense records an expense; undoing it removes the expense again
+type AddExpense struct {
+	Expense models.Expense
+	created bool
+}
+
+func (c *AddExpense) Do(repo storage.Repository) error {
+	if c.created {
+		return repo.InsertExpense(c.Expense)
+	}
+	id, err := repo.AddExpense(c.Expense)
+	if err != nil {
+		return err
+	}
+	c.Expense.ID = id
+	c.created = true
+	return nil
+}
+
+func (c *AddExpense) Undo(repo storage.Repository) error {
+	return repo.DeleteExpense(c.Expense.ID)
+}
+
+func (c *AddExpense) Description() string {
+	return fmt.Sprintf("add %s expense of %s", c.Expense.Category, c.Expense.Amount.Format(c.Expense.Currency))
+}
+
+// UpdateExpense saves an edited expense; undoing it restores the previous details
+type UpdateExpense struct {
+	Expense models.Expense
+	Updated models.Expense
+}
+
+func (c *UpdateExpense) Do(repo storage.Repository) error {
+	return repo.UpdateExpense(c.Updated)
+}
+
+func (c *UpdateExpense) Undo(repo storage.Repository) error {
+	return repo.UpdateExpense(c.Expense)
+}
+
+func (c *UpdateExpense) Description() string {
+	return fmt.Sprintf("edit %s expense of %s", c.Updated.Category, c.Updated.Amount.Format(c.Updated.Currency))
+}
+
+// DeleteExpense removes an expense; undoing it puts the expense back
+type DeleteExpense struct {
+	Expense models.Expense
+}
+
+func (c *DeleteExpense) Do(repo storage.Repository) error {
+	return repo.DeleteExpense(c.Expense.ID)
+}
+
+func (c *DeleteExpense) Undo(repo storage.Repository) error {
+	return repo.InsertExpense(c.Expense)
+}
+
+func (c *DeleteExpense) Description() string {
+	return fmt.Sprintf("delete %s expense of %s", c.Expense.Category, c.Expense.Amount.Format(c.Expense.Currency))
+}
+
+// stopTimers stops the timers running on tasks and returns the commands that
+// stopped them, so that undoing them lets the timers run again
+func stopTimers(repo storage.Repository, tasks []models.Task) ([]*StopTimer, error) {
+	now := time.Now()
+	var stopped []*StopTimer
+	for _, t := range tasks {
+		if entry, ok := t.RunningEntry(); ok {
+			stop := &StopTimer{Task: t, Entry: entry, End: now}
+			if err := stop.Do(repo); err != nil {
+				return stopped, err
+			}
+			stopped = append(stopped, stop)
+		}
+	}
+	return stopped, nil
+}
+
+// restartTimers undoes the commands returned by stopTimers
+func restartTimers(repo storage.Repository, stopped []*StopTimer) error {
+	for _, stop := range stopped {
+		if err := stop.Undo(repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findProject(repo storage.Repository, projectID int) (models.Project, bool) {
+	for _, p := range append(repo.GetProjects(), repo.GetArchivedProjects()...) {
+		if p.ID == projectID {
+			return p, true
+		}
+	}
+	return models.Project{}, false
+}
+
+func findTask(repo storage.Repository, projectID, taskID int) (models.Task, bool) {
+	for _, t := range repo.GetTasks() {
+		if t.ProjectID == projectID && t.ID == taskID {
+			return t, true
+		}
+	}
+	return models.Task{}, false
+}
@@ -0,0 +1,431 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/storage"
+)
+
+// fixture is the data every command test starts from
+type fixture struct {
+	website int // fixed price project with a running timer on design
+	design  int
+	build   int
+	draft   int // trashed task of website
+	support int // hourly project with stopped time on fix
+	fix     int
+	entry   int
+	old     int // trashed project
+	shelved int // archived project
+}
+
+// newFixture returns a repository with the projects of the fixture, two
+// clients, Acme without projects and Globex, and an expense
+func newFixture(t *testing.T) (*storage.MemoryStorage, fixture) {
+	t.Helper()
+	repo := storage.NewMemoryStorage()
+	must := func(id int, err error) int {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	end := start.Add(2 * time.Hour)
+
+	var f fixture
+	must(repo.AddClient(models.Client{Name: "Acme", Currency: "USD"}))
+	client := must(repo.AddClient(models.Client{Name: "Globex", Currency: "USD"}))
+	f.website = must(repo.AddProject(models.Project{Name: "Website", ClientID: client, Status: "Active",
+		Billing: models.BillingFixed, Cost: models.Cents(1500), Currency: "USD"}))
+	f.design = must(repo.AddTask(f.website, models.Task{Title: "Design", Status: models.TaskStatusInProgress}))
+	must(repo.AddTimeEntry(f.website, f.design, models.TimeEntry{Start: start}))
+	f.build = must(repo.AddTask(f.website, models.Task{Title: "Build", Status: models.TaskStatusWaiting}))
+	f.draft = must(repo.AddTask(f.website, models.Task{Title: "Draft"}))
+	check(repo.DeleteTask(f.website, f.draft))
+
+	f.support = must(repo.AddProject(models.Project{Name: "Support", ClientID: client, Status: "Active",
+		Billing: models.BillingHourly, Rate: models.Cents(80), Currency: "USD"}))
+	f.fix = must(repo.AddTask(f.support, models.Task{Title: "Fix"}))
+	f.entry = must(repo.AddTimeEntry(f.support, f.fix, models.TimeEntry{Start: start, End: &end}))
+
+	f.old = must(repo.AddProject(models.Project{Name: "Old", Status: "Active"}))
+	must(repo.AddTask(f.old, models.Task{Title: "Leftover"}))
+	check(repo.DeleteProject(f.old))
+
+	f.shelved = must(repo.AddProject(models.Project{Name: "Shelved", Status: "Active"}))
+	shelvedTask := must(repo.AddTask(f.shelved, models.Task{Title: "Someday"}))
+	must(repo.AddTimeEntry(f.shelved, shelvedTask, models.TimeEntry{Start: start, End: &end}))
+	check(repo.SetProjectArchived(f.shelved, true))
+
+	must(repo.AddExpense(models.Expense{Date: "2026-03-01", Amount: models.Cents(25),
+		Currency: "USD", Category: "Software"}))
+	return repo, f
+}
+
+// state describes everything the commands can change, leaving out the
+// timestamps and IDs that differ when a command is redone
+func state(repo storage.Repository) string {
+	var b strings.Builder
+	tasks := func(ts []models.Task) {
+		for _, t := range ts {
+			fmt.Fprintf(&b, "  task %d %q %q %s %q trashed=%v\n", t.ID, t.Title, t.Description, t.Status,
+				t.CompletedDate, t.DeletedAt != nil)
+			for _, e := range t.TimeEntries {
+				fmt.Fprintf(&b, "    entry %s running=%v note=%q invoiced=%v\n",
+					e.Start.Format(time.RFC3339), e.Running(), e.Note, e.InvoiceID != 0)
+			}
+		}
+	}
+	projects := func(kind string, ps []models.Project) {
+		for _, p := range ps {
+			fmt.Fprintf(&b, "%s project %d %q client=%d %s %s %s deadline=%q invoiced=%v\n", kind, p.ID, p.Name,
+				p.ClientID, p.Status, p.Billing, p.Cost.Format(p.Currency), p.Deadline, p.InvoiceID != 0)
+			tasks(p.Tasks)
+		}
+	}
+	projects("active", repo.GetProjects())
+	projects("archived", repo.GetArchivedProjects())
+	trashedProjects, trashedTasks := repo.GetTrash()
+	projects("trashed", trashedProjects)
+	b.WriteString("trashed tasks\n")
+	tasks(trashedTasks)
+	for _, c := range repo.GetClients() {
+		fmt.Fprintf(&b, "client %d %q %q\n", c.ID, c.Name, c.Email)
+	}
+	for _, inv := range repo.GetInvoices() {
+		fmt.Fprintf(&b, "invoice to %q for %s\n", inv.Client, inv.Total().Format(inv.Currency))
+	}
+	for _, p := range repo.GetPayments() {
+		fmt.Fprintf(&b, "payment %s %s invoice=%v project=%d\n", p.Date, p.Amount.Format("USD"), p.InvoiceID != 0, p.ProjectID)
+	}
+	for _, e := range repo.GetExpenses() {
+		fmt.Fprintf(&b, "expense %d %s %s %q %q\n", e.ID, e.Date, e.Amount.Format(e.Currency), e.Category, e.Note)
+	}
+	return b.String()
+}
+
+func project(t *testing.T, repo storage.Repository, id int) models.Project {
+	t.Helper()
+	p, ok := findProject(repo, id)
+	if !ok {
+		trashed, _ := repo.GetTrash()
+		for _, p := range trashed {
+			if p.ID == id {
+				return p
+			}
+		}
+		t.Fatalf("project %d not found", id)
+	}
+	return p
+}
+
+func task(t *testing.T, repo storage.Repository, projectID, id int) models.Task {
+	t.Helper()
+	_, trashed := repo.GetTrash()
+	for _, task := range append(project(t, repo, projectID).Tasks, trashed...) {
+		if task.ProjectID == projectID && task.ID == id {
+			return task
+		}
+	}
+	t.Fatalf("task %d of project %d not found", id, projectID)
+	return models.Task{}
+}
+
+// running reports whether a timer runs on the task
+func running(t *testing.T, repo storage.Repository, projectID, id int) bool {
+	t.Helper()
+	_, ok := task(t, repo, projectID, id).RunningEntry()
+	return ok
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  func(t *testing.T, repo storage.Repository, f fixture) Command
+		// done checks the repository after the command was done
+		done func(t *testing.T, repo storage.Repository, f fixture)
+	}{
+		{name: "add project",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &AddProject{Project: models.Project{Name: "Shop", Status: "Active", Billing: models.BillingFixed}}
+			}},
+		{name: "add task",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &AddTask{Task: models.Task{ProjectID: f.website, Title: "Launch", Status: models.TaskStatusWaiting}}
+			}},
+		{name: "delete project stops its timer",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &DeleteProject{Project: project(t, repo, f.website)}
+			},
+			done: func(t *testing.T, repo storage.Repository, f fixture) {
+				if running(t, repo, f.website, f.design) {
+					t.Error("the timer of a trashed project still runs")
+				}
+			}},
+		{name: "delete task stops its timer",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &DeleteTask{Task: task(t, repo, f.website, f.design)}
+			},
+			done: func(t *testing.T, repo storage.Repository, f fixture) {
+				if running(t, repo, f.website, f.design) {
+					t.Error("the timer of a trashed task still runs")
+				}
+			}},
+		{name: "restore project",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &RestoreProject{Project: project(t, repo, f.old)}
+			}},
+		{name: "restore task",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &RestoreTask{Task: task(t, repo, f.website, f.draft)}
+			}},
+		{name: "purge project",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &PurgeProject{Project: project(t, repo, f.old)}
+			}},
+		{name: "purge task",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &PurgeTask{Task: task(t, repo, f.website, f.draft)}
+			}},
+		{name: "set project status",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &SetProjectStatus{Project: project(t, repo, f.website), NewStatus: "Completed"}
+			}},
+		{name: "update project",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				p := project(t, repo, f.website)
+				updated := p
+				updated.Name, updated.Cost, updated.Deadline = "Web shop", models.Cents(2500), "2026-06-30"
+				return &UpdateProject{Project: p, Updated: updated}
+			}},
+		{name: "update task",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				task := task(t, repo, f.website, f.build)
+				updated := task
+				updated.Title, updated.Description = "Build pages", "All of them"
+				return &UpdateTask{Task: task, Updated: updated}
+			}},
+		{name: "archive project stops its timer",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &SetProjectArchived{Project: project(t, repo, f.website), Archived: true}
+			},
+			done: func(t *testing.T, repo storage.Repository, f fixture) {
+				if running(t, repo, f.website, f.design) {
+					t.Error("the timer of an archived project still runs")
+				}
+			}},
+		{name: "unarchive project",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &SetProjectArchived{Project: project(t, repo, f.shelved), Archived: false}
+			}},
+		{name: "set task status",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &SetTaskStatus{Task: task(t, repo, f.website, f.build), NewStatus: models.TaskStatusDone,
+					NewCompletedDate: "2026-03-05"}
+			}},
+		{name: "start timer",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &StartTimer{Task: task(t, repo, f.website, f.build),
+					Entry: models.TimeEntry{Start: time.Date(2026, 3, 3, 10, 0, 0, 0, time.Local)}}
+			}},
+		{name: "stop timer",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				design := task(t, repo, f.website, f.design)
+				entry, _ := design.RunningEntry()
+				return &StopTimer{Task: design, Entry: entry, End: entry.Start.Add(time.Hour), Note: "Mockups"}
+			}},
+		{name: "add invoice",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &AddInvoice{Invoice: models.Invoice{Client: "Globex", Currency: "USD", Items: []models.InvoiceItem{
+					{Description: "Support", Quantity: 2, UnitPrice: models.Cents(80), ProjectID: f.support,
+						Entries: []models.TimeEntryRef{{TaskID: f.fix, EntryID: f.entry}}},
+					{Description: "Website", Quantity: 1, UnitPrice: models.Cents(1500), ProjectID: f.website},
+				}}}
+			}},
+		{name: "record payment",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &RecordPayment{Payment: models.Payment{Date: "2026-03-10", Amount: models.Cents(500), ProjectID: f.website},
+					Target: "Website", Currency: "USD"}
+			}},
+		{name: "add client",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &AddClient{Client: models.Client{Name: "Initech", Currency: "USD"}}
+			}},
+		{name: "update client",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				acme := repo.GetClients()[0]
+				updated := acme
+				updated.Email = "billing@acme.test"
+				return &UpdateClient{Client: acme, Updated: updated}
+			}},
+		{name: "delete client",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &DeleteClient{Client: repo.GetClients()[0]}
+			}},
+		{name: "add expense",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &AddExpense{Expense: models.Expense{Date: "2026-03-04", Amount: models.Cents(12), Currency: "USD",
+					Category: "Travel"}}
+			}},
+		{name: "update expense",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				e := repo.GetExpenses()[0]
+				updated := e
+				updated.Amount, updated.Note = models.Cents(30), "Yearly plan"
+				return &UpdateExpense{Expense: e, Updated: updated}
+			}},
+		{name: "delete expense",
+			cmd: func(t *testing.T, repo storage.Repository, f fixture) Command {
+				return &DeleteExpense{Expense: repo.GetExpenses()[0]}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, f := newFixture(t)
+			before := state(repo)
+			cmd := tt.cmd(t, repo, f)
+			var h History
+
+			if err := h.Execute(repo, cmd); err != nil {
+				t.Fatal(err)
+			}
+			after := state(repo)
+			if after == before {
+				t.Fatal("the command changed nothing")
+			}
+			if tt.done != nil {
+				tt.done(t, repo, f)
+			}
+
+			if undone, err := h.Undo(repo); err != nil || undone != cmd {
+				t.Fatalf("undo returned %v, %v", undone, err)
+			}
+			if got := state(repo); got != before {
+				t.Errorf("undo left\n%s\nwant\n%s", got, before)
+			}
+			if redone, err := h.Redo(repo); err != nil || redone != cmd {
+				t.Fatalf("redo returned %v, %v", redone, err)
+			}
+			if got := state(repo); got != after {
+				t.Errorf("redo left\n%s\nwant\n%s", got, after)
+			}
+			if tt.done != nil {
+				tt.done(t, repo, f)
+			}
+			if _, err := h.Undo(repo); err != nil {
+				t.Fatal(err)
+			}
+			if got := state(repo); got != before {
+				t.Errorf("second undo left\n%s\nwant\n%s", got, before)
+			}
+		})
+	}
+}
+
+func TestCommandsOnMissingData(t *testing.T) {
+	missingProject := models.Project{ID: 99, Name: "Gone"}
+	missingTask := models.Task{ProjectID: 1, ID: 99, Title: "Gone"}
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{"add task to a missing project", &AddTask{Task: models.Task{ProjectID: 99, Title: "Launch"}}},
+		{"delete missing project", &DeleteProject{Project: missingProject}},
+		{"delete missing task", &DeleteTask{Task: missingTask}},
+		{"restore missing project", &RestoreProject{Project: missingProject}},
+		{"restore missing task", &RestoreTask{Task: missingTask}},
+		{"purge missing project", &PurgeProject{Project: missingProject}},
+		{"purge missing task", &PurgeTask{Task: missingTask}},
+		{"set status of missing project", &SetProjectStatus{Project: missingProject, NewStatus: "Completed"}},
+		{"update missing project", &UpdateProject{Project: missingProject, Updated: missingProject}},
+		{"update missing task", &UpdateTask{Task: missingTask, Updated: missingTask}},
+		{"archive missing project", &SetProjectArchived{Project: missingProject, Archived: true}},
+		{"move missing task", &SetTaskStatus{Task: missingTask, NewStatus: models.TaskStatusDone}},
+		{"start timer on missing task", &StartTimer{Task: missingTask, Entry: models.TimeEntry{Start: time.Now()}}},
+		{"stop missing timer", &StopTimer{Task: missingTask, Entry: models.TimeEntry{ID: 1}, End: time.Now()}},
+		{"invoice missing project", &AddInvoice{Invoice: models.Invoice{Client: "Globex",
+			Items: []models.InvoiceItem{{Description: "Gone", Quantity: 1, ProjectID: 99}}}}},
+		{"update missing client", &UpdateClient{Client: models.Client{ID: 99}, Updated: models.Client{ID: 99, Name: "Gone"}}},
+		{"delete missing client", &DeleteClient{Client: models.Client{ID: 99}}},
+		{"update missing expense", &UpdateExpense{Expense: models.Expense{ID: 99}, Updated: models.Expense{ID: 99}}},
+		{"delete missing expense", &DeleteExpense{Expense: models.Expense{ID: 99}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newFixture(t)
+			before := state(repo)
+			var h History
+			if err := h.Execute(repo, tt.cmd); err == nil {
+				t.Fatal("the command succeeded")
+			}
+			if got := state(repo); got != before {
+				t.Errorf("a failed command changed\n%s\ninto\n%s", before, got)
+			}
+			if cmd, err := h.Undo(repo); cmd != nil || err != nil {
+				t.Errorf("a failed command can be undone: %v, %v", cmd, err)
+			}
+		})
+	}
+}
+
+func TestUndoAfterTheDataIsGone(t *testing.T) {
+	repo, f := newFixture(t)
+	var h History
+	if err := h.Execute(repo, &SetTaskStatus{Task: task(t, repo, f.website, f.build), NewStatus: models.TaskStatusDone}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteProject(f.website); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.PurgeProject(f.website); err != nil {
+		t.Fatal(err)
+	}
+
+	// A command that cannot be undone stays on the undo stack
+	for i := 0; i < 2; i++ {
+		if cmd, err := h.Undo(repo); cmd == nil || err == nil {
+			t.Fatalf("undoing a change to a purged task returned %v, %v", cmd, err)
+		}
+	}
+	if cmd, _ := h.Redo(repo); cmd != nil {
+		t.Errorf("a command that was not undone was redone: %v", cmd)
+	}
+}
+
+func TestExecuteClearsRedo(t *testing.T) {
+	repo, f := newFixture(t)
+	var h History
+	for _, title := range []string{"One", "Two"} {
+		if err := h.Execute(repo, &AddTask{Task: models.Task{ProjectID: f.website, Title: title}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := h.Undo(repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Execute(repo, &AddTask{Task: models.Task{ProjectID: f.website, Title: "Three"}}); err != nil {
+		t.Fatal(err)
+	}
+	if cmd, _ := h.Redo(repo); cmd != nil {
+		t.Errorf("redid %q after a new command", cmd.Description())
+	}
+	var undone []string
+	for cmd, _ := h.Undo(repo); cmd != nil; cmd, _ = h.Undo(repo) {
+		undone = append(undone, cmd.Description())
+	}
+	if got := strings.Join(undone, ", "); got != "add task 'Three', add task 'One'" {
+		t.Errorf("undid %s", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package invoice works out which work can be billed and renders invoices to files
+package invoice
+
+import (
+	"math"
+	"sort"
+
+	"freelancy.go/internal/models"
+)
+
+// Clients returns the clients that have unbilled work, sorted by name
+func Clients(projects []models.Project) []string {
+	seen := make(map[string]bool)
+	var clients []string
+	for _, p := range projects {
+		if seen[p.Client] || len(Billable(p)) == 0 {
+			continue
+		}
+		seen[p.Client] = true
+		clients = append(clients, p.Client)
+	}
+	sort.Strings(clients)
+	return clients
+}
+
+// ForClient returns the unbilled work of all projects of a client as invoice items
+func ForClient(projects []models.Project, client string) []models.InvoiceItem {
+	var items []models.InvoiceItem
+	for _, p := range projects {
+		if p.Client == client {
+			items = append(items, Billable(p)...)
+		}
+	}
+	return items
+}
+
+// Billable returns the work of a project that has not been invoiced yet.
+// A completed fixed price project is a single item; hourly projects get an
+// item per task for its finished time entries and daily projects a single
+// item for the days worked. Running timers are never billed, and neither is
+// work that adds up to nothing, such as a day that was billed already
+func Billable(p models.Project) []models.InvoiceItem {
+	switch p.Billing {
+	case models.BillingHourly:
+		var items []models.InvoiceItem
+		for _, t := range p.Tasks {
+			var hours float64
+			var refs []models.TimeEntryRef
+			for _, e := range t.TimeEntries {
+				if e.Running() || e.InvoiceID != 0 {
+					continue
+				}
+				hours += e.End.Sub(e.Start).Hours()
+				refs = append(refs, models.TimeEntryRef{TaskID: t.ID, EntryID: e.ID})
+			}
+			quantity := math.Round(hours*100) / 100
+			if quantity == 0 {
+				continue
+			}
+			items = append(items, models.InvoiceItem{
+				Description: p.Name + ": " + t.Title,
+				Quantity:    quantity,
+				Unit:        "h",
+				UnitPrice:   p.Rate,
+				ProjectID:   p.ID,
+				Entries:     refs,
+			})
+		}
+		return items
+
+	case models.BillingDaily:
+		// Days already billed through other entries are not charged again,
+		// but their new entries are still marked as billed
+		billedDays := make(map[string]bool)
+		for _, t := range p.Tasks {
+			for _, e := range t.TimeEntries {
+				if e.InvoiceID != 0 {
+					billedDays[day(e)] = true
+				}
+			}
+		}
+		days := make(map[string]bool)
+		var refs []models.TimeEntryRef
+		for _, t := range p.Tasks {
+			for _, e := range t.TimeEntries {
+				if e.Running() || e.InvoiceID != 0 {
+					continue
+				}
+				if !billedDays[day(e)] {
+					days[day(e)] = true
+				}
+				refs = append(refs, models.TimeEntryRef{TaskID: t.ID, EntryID: e.ID})
+			}
+		}
+		if len(days) == 0 {
+			return nil
+		}
+		return []models.InvoiceItem{{
+			Description: p.Name,
+			Quantity:    float64(len(days)),
+			Unit:        "days",
+			UnitPrice:   p.Rate,
+			ProjectID:   p.ID,
+			Entries:     refs,
+		}}
+
+	default:
+		if p.Status != "Completed" || p.InvoiceID != 0 {
+			return nil
+		}
+		return []models.InvoiceItem{{
+			Description: p.Name,
+			Quantity:    1,
+			UnitPrice:   p.Cost,
+			ProjectID:   p.ID,
+		}}
+	}
+}
+
+// day returns the local date the work of a time entry started on
+func day(e models.TimeEntry) string {
+	return e.Start.Local().Format("2006-01-02")
+}
@@ -0,0 +1,100 @@
+package invoice
+
+import (
+	"fmt"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/internal/pdf"
+)
+
+// Right edges of the number columns of the item table
+const (
+	quantityColumn  = 390
+	unitPriceColumn = 470
+	amountColumn    = pdf.PageWidth - pdf.Margin
+)
+
+// PDF renders an invoice as a PDF document below the sender's letterhead,
+// with the sender's bank details as payment instructions
+func PDF(inv models.Invoice, lh pdf.Letterhead) (*pdf.Document, error) {
+	doc := pdf.New("Invoice " + inv.Number)
+	page := doc.AddPage()
+	y, err := doc.DrawLetterhead(page, lh)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Text(pdf.Margin, y, 22, true, "INVOICE")
+	page.TextRight(amountColumn, y-8, 10, true, inv.Number)
+	page.TextRight(amountColumn, y+6, 9, false, "Issue date: "+inv.IssueDate)
+	page.TextRight(amountColumn, y+18, 9, false, "Due date: "+inv.DueDate)
+	y += 40
+
+	page.Text(pdf.Margin, y, 9, true, "Bill to")
+	page.Text(pdf.Margin, y+14, 11, false, inv.Client)
+	y += 44
+
+	tableHeader := func(y float64) float64 {
+		page.Rect(pdf.Margin-4, y-12, amountColumn-pdf.Margin+8, 18, 0.9)
+		page.Text(pdf.Margin, y, 9, true, "Description")
+		page.TextRight(quantityColumn, y, 9, true, "Quantity")
+		page.TextRight(unitPriceColumn, y, 9, true, "Unit price")
+		page.TextRight(amountColumn, y, 9, true, "Amount")
+		return y + 22
+	}
+	y = tableHeader(y)
+
+	// Long descriptions wrap, and an item goes to the next page whole unless
+	// it would not fit below the header of a new page, at pdf.Margin+34, either
+	const lastRow = pdf.PageHeight - pdf.Margin - 40
+	nextPage := func() {
+		page = doc.AddPage()
+		y = tableHeader(pdf.Margin + 12)
+	}
+	for _, item := range inv.Items {
+		lines := pdf.Wrap(item.Description, quantityColumn-pdf.Margin-70, 10, false)
+		wrapped := 12 * float64(len(lines)-1)
+		if y > lastRow || y+wrapped > lastRow && pdf.Margin+34+wrapped <= lastRow {
+			nextPage()
+		}
+		page.TextRight(quantityColumn, y, 10, false, FormatQuantity(item))
+		page.TextRight(unitPriceColumn, y, 10, false, item.UnitPrice.Format(inv.Currency))
+		page.TextRight(amountColumn, y, 10, false, item.Amount().Format(inv.Currency))
+		for i, line := range lines {
+			if i > 0 {
+				if y += 12; y > lastRow {
+					nextPage()
+				}
+			}
+			page.Text(pdf.Margin, y, 10, false, line)
+		}
+		y += 18
+	}
+
+	// Keep the totals and payment details together on one page
+	bank := pdf.Lines(lh.Bank)
+	if y+90+12*float64(len(bank)) > pdf.PageHeight-pdf.Margin {
+		page = doc.AddPage()
+		y = pdf.Margin + 12
+	}
+	page.Line(unitPriceColumn-100, y-8, amountColumn, y-8, 0.5)
+	y += 6
+	page.TextRight(unitPriceColumn, y, 10, false, "Subtotal")
+	page.TextRight(amountColumn, y, 10, false, inv.Subtotal().Format(inv.Currency))
+	y += 16
+	page.TextRight(unitPriceColumn, y, 10, false, fmt.Sprintf("Tax (%s%%)", formatNumber(inv.TaxRate)))
+	page.TextRight(amountColumn, y, 10, false, inv.Tax().Format(inv.Currency))
+	y += 20
+	page.TextRight(unitPriceColumn, y, 12, true, "Total due")
+	page.TextRight(amountColumn, y, 12, true, inv.Total().Format(inv.Currency))
+	y += 40
+
+	if len(bank) > 0 {
+		page.Text(pdf.Margin, y, 9, true, "Please pay by "+inv.DueDate+" to")
+		for _, line := range bank {
+			y += 12
+			page.Text(pdf.Margin, y, 9, false, line)
+		}
+	}
+	return doc, nil
+}
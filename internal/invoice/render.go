@@ -0,0 +1,175 @@
+package invoice
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/internal/pdf"
+)
+
+// Formats lists the file extensions Write renders an invoice to
+var Formats = []string{"md", "html", "txt", "pdf"}
+
+// Write renders an invoice as Markdown, HTML, plain text and PDF into dir,
+// named after the invoice number, and returns the paths of the files written.
+// The letterhead is printed at the top of the PDF
+func Write(inv models.Invoice, dir string, lh pdf.Letterhead) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, format := range Formats {
+		var content string
+		switch format {
+		case "md":
+			content = Markdown(inv)
+		case "html":
+			var err error
+			if content, err = HTML(inv); err != nil {
+				return paths, err
+			}
+		case "txt":
+			content = Text(inv)
+		case "pdf":
+			doc, err := PDF(inv, lh)
+			if err != nil {
+				return paths, err
+			}
+			var b strings.Builder
+			if err := doc.Write(&b); err != nil {
+				return paths, err
+			}
+			content = b.String()
+		}
+		path := filepath.Join(dir, inv.Number+"."+format)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// Markdown renders an invoice as a Markdown document with a table of items
+func Markdown(inv models.Invoice) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "# Invoice %s\n\n", inv.Number)
+	fmt.Fprintf(&s, "**Bill to:** %s  \n", escapeMarkdown(inv.Client))
+	fmt.Fprintf(&s, "**Issue date:** %s  \n", inv.IssueDate)
+	fmt.Fprintf(&s, "**Due date:** %s\n\n", inv.DueDate)
+
+	s.WriteString("| Description | Quantity | Unit price | Amount |\n")
+	s.WriteString("|---|---:|---:|---:|\n")
+	for _, item := range inv.Items {
+		fmt.Fprintf(&s, "| %s | %s | %s | %s |\n",
+			escapeMarkdown(item.Description), FormatQuantity(item), item.UnitPrice.Format(inv.Currency), item.Amount().Format(inv.Currency))
+	}
+
+	fmt.Fprintf(&s, "\n| | |\n|---|---:|\n")
+	fmt.Fprintf(&s, "| Subtotal | %s |\n", inv.Subtotal().Format(inv.Currency))
+	fmt.Fprintf(&s, "| Tax (%s%%) | %s |\n", formatNumber(inv.TaxRate), inv.Tax().Format(inv.Currency))
+	fmt.Fprintf(&s, "| **Total due** | **%s** |\n", inv.Total().Format(inv.Currency))
+	return s.String()
+}
+
+// Text renders an invoice as plain text with aligned columns
+func Text(inv models.Invoice) string {
+	width := len("Description")
+	for _, item := range inv.Items {
+		if n := len([]rune(item.Description)); n > width {
+			width = n
+		}
+	}
+	line := strings.Repeat("-", width+40) + "\n"
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "INVOICE %s\n\n", inv.Number)
+	fmt.Fprintf(&s, "Bill to:    %s\n", inv.Client)
+	fmt.Fprintf(&s, "Issue date: %s\n", inv.IssueDate)
+	fmt.Fprintf(&s, "Due date:   %s\n\n", inv.DueDate)
+
+	fmt.Fprintf(&s, "%-*s  %10s  %12s  %12s\n", width, "Description", "Quantity", "Unit price", "Amount")
+	s.WriteString(line)
+	for _, item := range inv.Items {
+		fmt.Fprintf(&s, "%-*s  %10s  %12s  %12s\n", width, item.Description,
+			FormatQuantity(item), item.UnitPrice.Format(inv.Currency), item.Amount().Format(inv.Currency))
+	}
+	s.WriteString(line)
+
+	total := func(label, amount string) {
+		fmt.Fprintf(&s, "%*s  %12s\n", width+26, label, amount)
+	}
+	total("Subtotal", inv.Subtotal().Format(inv.Currency))
+	total("Tax ("+formatNumber(inv.TaxRate)+"%)", inv.Tax().Format(inv.Currency))
+	total("Total due", inv.Total().Format(inv.Currency))
+	return s.String()
+}
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money":    models.Money.Format,
+	"quantity": FormatQuantity,
+	"number":   formatNumber,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.4em; border-bottom: 1px solid #ddd; }
+th { text-align: left; }
+.num { text-align: right; }
+.total td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>
+<strong>Bill to:</strong> {{.Client}}<br>
+<strong>Issue date:</strong> {{.IssueDate}}<br>
+<strong>Due date:</strong> {{.DueDate}}
+</p>
+<table>
+<tr><th>Description</th><th class="num">Quantity</th><th class="num">Unit price</th><th class="num">Amount</th></tr>
+{{range .Items}}<tr><td>{{.Description}}</td><td class="num">{{quantity .}}</td><td class="num">{{money .UnitPrice $.Currency}}</td><td class="num">{{money .Amount $.Currency}}</td></tr>
+{{end}}<tr><td colspan="3" class="num">Subtotal</td><td class="num">{{money .Subtotal .Currency}}</td></tr>
+<tr><td colspan="3" class="num">Tax ({{number .TaxRate}}%)</td><td class="num">{{money .Tax .Currency}}</td></tr>
+<tr class="total"><td colspan="3" class="num">Total due</td><td class="num">{{money .Total .Currency}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// HTML renders an invoice as a standalone HTML page
+func HTML(inv models.Invoice) (string, error) {
+	var s strings.Builder
+	if err := htmlTemplate.Execute(&s, inv); err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
+
+// FormatQuantity formats the quantity of an item together with its unit
+func FormatQuantity(item models.InvoiceItem) string {
+	if item.Unit == "" {
+		return formatNumber(item.Quantity)
+	}
+	return formatNumber(item.Quantity) + " " + item.Unit
+}
+
+// formatNumber formats a number without trailing zeros
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// escapeMarkdown keeps user text from breaking the Markdown table
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// Client is a customer that projects are done for. Projects refer to their
+// client by ID and carry a copy of its name
+type Client struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Address  string `json:"address,omitempty"`
+	Currency string `json:"currency,omitempty"` // ISO 4217 code such as USD or EUR
+	Rate     Money  `json:"rate,omitempty"`     // default hourly rate of new projects
+	Notes    string `json:"notes,omitempty"`
+}
+
+// ClientSummary totals the work done for a client. Amounts are in the
+// reporting currency
+type ClientSummary struct {
+	Client       Client
+	Billed       Money // invoiced, including tax
+	Paid         Money
+	Outstanding  Money // still owed for invoiced and completed work
+	Projects     int
+	AverageValue Money // average price or income of a project
+	// DaysToPay is the average number of days from issuing an invoice to
+	// its full payment, or -1 when no invoice has been paid yet
+	DaysToPay float64
+	Hours     float64 // time tracked on the client's projects
+	// Missing are the currencies of the client's projects that have no rate
+	// to the reporting currency, sorted. Their amounts are left out
+	Missing []string
+}
+
+// Summarize totals the projects of each client and the invoices and payments
+// for them. Projects are matched to their client by ID, and their amounts
+// converted at today's rate; amounts without a rate are left out and their
+// currency listed in Missing
+func (l Ledger) Summarize(clients []Client, projects []Project, now time.Time, convert Converter) []ClientSummary {
+	summaries := make([]ClientSummary, len(clients))
+	index := make(map[int]int, len(clients))
+	for i, c := range clients {
+		summaries[i] = ClientSummary{Client: c, DaysToPay: -1}
+		index[c.ID] = i
+	}
+
+	value := make([]Money, len(clients))
+	for _, p := range projects {
+		i, ok := index[p.ClientID]
+		if p.ClientID == 0 || !ok {
+			continue
+		}
+		s := &summaries[i]
+		reporting := func(amount Money) Money {
+			converted, ok := convert(amount, p.Currency, now)
+			if ok {
+				return converted
+			}
+			currency := p.Currency
+			if currency == "" {
+				currency = DefaultCurrency
+			}
+			if !slices.Contains(s.Missing, currency) {
+				s.Missing = append(s.Missing, currency)
+				sort.Strings(s.Missing)
+			}
+			return 0
+		}
+		s.Projects++
+		s.Billed += reporting(l.projectBilled(p.ID))
+		paid := l.ProjectPaid(p.ID)
+		s.Paid += reporting(paid)
+		if owed := l.ProjectDue(p, now) - paid; owed > 1 {
+			s.Outstanding += reporting(owed)
+		}
+		s.Hours += p.TrackedTime(now).Hours()
+		if p.Billing == BillingFixed {
+			value[i] += reporting(p.Cost)
+		} else {
+			value[i] += reporting(p.Income(now))
+		}
+	}
+
+	// An invoice belongs to the client of the projects it bills
+	clientOf := make(map[int]int, len(projects))
+	for _, p := range projects {
+		clientOf[p.ID] = p.ClientID
+	}
+	days := make([]float64, len(clients))
+	paidInvoices := make([]int, len(clients))
+	for _, inv := range l.invoices {
+		if len(inv.Items) == 0 {
+			continue
+		}
+		i, ok := index[clientOf[inv.Items[0].ProjectID]]
+		if !ok {
+			continue
+		}
+		paidOn, paid := l.PaidOn(inv)
+		issued, err := time.Parse("2006-01-02", inv.IssueDate)
+		if !paid || err != nil {
+			continue
+		}
+		if date, err := time.Parse("2006-01-02", paidOn); err == nil {
+			days[i] += date.Sub(issued).Hours() / 24
+			paidInvoices[i]++
+		}
+	}
+
+	for i := range summaries {
+		if summaries[i].Projects > 0 {
+			summaries[i].AverageValue = value[i] / Money(summaries[i].Projects)
+		}
+		if paidInvoices[i] > 0 {
+			summaries[i].DaysToPay = days[i] / float64(paidInvoices[i])
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Expense is money spent on a project, such as stock assets, hosting or a
+// subcontractor, or on the business in general when it has no project
+type Expense struct {
+	ID        int    `json:"id"`
+	Date      string `json:"date"` // YYYY-MM-DD
+	Amount    Money  `json:"amount"`
+	Currency  string `json:"currency"`
+	Category  string `json:"category"`
+	Note      string `json:"note,omitempty"`
+	Receipt   string `json:"receipt,omitempty"`    // path of a scanned receipt or bill
+	ProjectID int    `json:"project_id,omitempty"` // 0 for general business expenses
+}
+
+// Spent returns the day of the expense, or false when its date is malformed
+func (e Expense) Spent() (time.Time, bool) {
+	date, err := time.ParseInLocation("2006-01-02", e.Date, time.Local)
+	return date, err == nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Money is an amount in hundredths of its currency unit, such as cents.
+// Amounts are whole numbers so that adding them up never drifts by rounding
+type Money int64
+
+// DefaultCurrency is the currency of amounts recorded before projects had
+// one, and of projects and invoices that do not name theirs
+const DefaultCurrency = "USD"
+
+// Cents converts an amount in currency units to Money, rounding to the
+// nearest hundredth
+func Cents(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// Float returns the amount in currency units
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
+
+// Times returns the amount multiplied by factor, rounded to the nearest
+// hundredth
+func (m Money) Times(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// currencySymbols are written before amounts in place of the currency code
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// Format writes the amount with two decimals after the symbol of currency,
+// or after its code when it has no common symbol
+func (m Money) Format(currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	prefix, ok := currencySymbols[currency]
+	if !ok {
+		prefix = currency + " "
+	}
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, prefix, m/100, m%100)
+}
+
+// Converter converts an amount in currency into the reporting currency at
+// the rate of the given day. It returns false when there is no exchange rate
+type Converter func(amount Money, currency string, date time.Time) (Money, bool)
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Payment is money received from a client, recorded against either an
+// invoice or directly against a project. Partial payments are allowed
+type Payment struct {
+	ID        int    `json:"id"`
+	Date      string `json:"date"` // YYYY-MM-DD
+	Amount    Money  `json:"amount"`
+	Method    string `json:"method,omitempty"` // e.g. bank transfer, card, cash
+	ProjectID int    `json:"project_id,omitempty"`
+	InvoiceID int    `json:"invoice_id,omitempty"`
+}
+
+// Payment states of projects and invoices
+const (
+	PaymentUnbilled = "unbilled"
+	PaymentInvoiced = "invoiced"
+	PaymentPartial  = "partially paid"
+	PaymentPaid     = "paid"
+	PaymentOverdue  = "overdue"
+)
+
+// Ledger relates projects and invoices to the payments received for them.
+// A payment of an invoice is shared between its projects in proportion to
+// their lines on the invoice
+type Ledger struct {
+	invoices []Invoice
+	payments []Payment
+}
+
+// NewLedger creates a ledger of the given invoices and payments
+func NewLedger(invoices []Invoice, payments []Payment) Ledger {
+	return Ledger{invoices: invoices, payments: payments}
+}
+
+// InvoicePayments returns the payments recorded against an invoice
+func (l Ledger) InvoicePayments(invoiceID int) []Payment {
+	var payments []Payment
+	for _, pay := range l.payments {
+		if pay.InvoiceID == invoiceID {
+			payments = append(payments, pay)
+		}
+	}
+	return payments
+}
+
+// InvoicePaid returns how much of an invoice has been paid
+func (l Ledger) InvoicePaid(invoiceID int) Money {
+	var paid Money
+	for _, pay := range l.InvoicePayments(invoiceID) {
+		paid += pay.Amount
+	}
+	return paid
+}
+
+// InvoiceState returns whether an invoice is paid, partially paid, overdue
+// or waiting for payment on the given day (YYYY-MM-DD)
+func (l Ledger) InvoiceState(inv Invoice, today string) string {
+	paid := l.InvoicePaid(inv.ID)
+	switch {
+	case covers(paid, inv.Total()):
+		return PaymentPaid
+	case inv.DueDate < today:
+		return PaymentOverdue
+	case paid > 0:
+		return PaymentPartial
+	}
+	return PaymentInvoiced
+}
+
+// PaidOn returns the day (YYYY-MM-DD) on which an invoice was paid in full
+func (l Ledger) PaidOn(inv Invoice) (string, bool) {
+	payments := l.InvoicePayments(inv.ID)
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].Date < payments[j].Date
+	})
+	var paid Money
+	for _, pay := range payments {
+		paid += pay.Amount
+		if covers(paid, inv.Total()) {
+			return pay.Date, true
+		}
+	}
+	return "", false
+}
+
+// ProjectState returns the payment state of a project on the given day.
+// A project is unbilled until it appears on an invoice or receives a
+// payment, and overdue while any of its invoices is
+func (l Ledger) ProjectState(p Project, today string, now time.Time) string {
+	billed := l.projectBilled(p.ID)
+	overdue := false
+	for _, inv := range l.invoices {
+		if projectShare(inv, p.ID) > 0 && l.InvoiceState(inv, today) == PaymentOverdue {
+			overdue = true
+		}
+	}
+
+	paid := l.ProjectPaid(p.ID)
+	due := l.ProjectDue(p, now)
+	switch {
+	case due > 0 && covers(paid, due):
+		return PaymentPaid
+	case overdue:
+		return PaymentOverdue
+	case paid > 0:
+		return PaymentPartial
+	case billed > 0:
+		return PaymentInvoiced
+	}
+	return PaymentUnbilled
+}
+
+// ProjectDue returns what the client owes for a project in total: the
+// amounts invoiced for it including tax, or its income so far while it has
+// not been invoiced
+func (l Ledger) ProjectDue(p Project, now time.Time) Money {
+	if billed := l.projectBilled(p.ID); billed > 0 {
+		return billed
+	}
+	return p.Income(now)
+}
+
+// projectBilled returns the amounts invoiced for a project including tax
+func (l Ledger) projectBilled(projectID int) Money {
+	var billed Money
+	for _, inv := range l.invoices {
+		billed += inv.Total().Times(projectShare(inv, projectID))
+	}
+	return billed
+}
+
+// ProjectPaid returns the payments received for a project, directly or
+// through its share of paid invoices
+func (l Ledger) ProjectPaid(projectID int) Money {
+	var paid Money
+	for _, r := range l.Receipts(projectID) {
+		paid += r.Amount
+	}
+	return paid
+}
+
+// Receipts returns the money received for a project by the day it was paid
+func (l Ledger) Receipts(projectID int) []Earning {
+	var receipts []Earning
+	for _, pay := range l.payments {
+		var amount Money
+		switch {
+		case pay.ProjectID == projectID && pay.InvoiceID == 0:
+			amount = pay.Amount
+		case pay.InvoiceID != 0:
+			for _, inv := range l.invoices {
+				if inv.ID == pay.InvoiceID {
+					amount = pay.Amount.Times(projectShare(inv, projectID))
+				}
+			}
+		}
+		if amount == 0 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", pay.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		receipts = append(receipts, Earning{Date: date, Amount: amount})
+	}
+	return receipts
+}
+
+// TaxReceived returns the part of the payments of an invoice that pays its
+// tax, by the day it was paid
+func (l Ledger) TaxReceived(inv Invoice) []Earning {
+	total := inv.Total()
+	if total == 0 {
+		return nil
+	}
+	var received []Earning
+	for _, pay := range l.InvoicePayments(inv.ID) {
+		date, err := time.ParseInLocation("2006-01-02", pay.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		received = append(received, Earning{Date: date, Amount: pay.Amount.Times(float64(inv.Tax()) / float64(total))})
+	}
+	return received
+}
+
+// projectShare returns the part of an invoice that bills the given project
+func projectShare(inv Invoice, projectID int) float64 {
+	subtotal := inv.Subtotal()
+	if subtotal == 0 {
+		return 0
+	}
+	var amount Money
+	for _, item := range inv.Items {
+		if item.ProjectID == projectID {
+			amount += item.Amount()
+		}
+	}
+	return float64(amount) / float64(subtotal)
+}
+
+// covers reports whether paid settles due, ignoring a cent lost when an
+// invoice payment is shared between projects
+func covers(paid, due Money) bool {
+	return paid > 0 && paid+1 >= due
+}
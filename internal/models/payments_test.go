@@ -0,0 +1,147 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// testInvoice bills 1000.00 of project 1 and 500.00 of project 2 with 20%
+// tax, 1800.00 in total, due on 2026-03-15
+var testInvoice = Invoice{ID: 1, Number: "INV-0001", IssueDate: "2026-03-01", DueDate: "2026-03-15", TaxRate: 20,
+	Items: []InvoiceItem{
+		{Description: "Website", Quantity: 1, UnitPrice: Cents(1000), ProjectID: 1},
+		{Description: "Logo", Quantity: 2, UnitPrice: Cents(250), ProjectID: 2},
+	}}
+
+func TestInvoiceState(t *testing.T) {
+	pay := func(date string, amount float64) Payment {
+		return Payment{Date: date, Amount: Cents(amount), InvoiceID: 1}
+	}
+	tests := []struct {
+		name     string
+		payments []Payment
+		today    string
+		state    string
+		paid     Money
+		paidOn   string
+	}{
+		{name: "waiting", today: "2026-03-10", state: PaymentInvoiced},
+		{name: "due today", today: "2026-03-15", state: PaymentInvoiced},
+		{name: "unpaid after the due date", today: "2026-03-16", state: PaymentOverdue},
+		{name: "partially paid", payments: []Payment{pay("2026-03-05", 800)},
+			today: "2026-03-10", state: PaymentPartial, paid: Cents(800)},
+		{name: "partially paid after the due date", payments: []Payment{pay("2026-03-05", 800)},
+			today: "2026-03-20", state: PaymentOverdue, paid: Cents(800)},
+		{name: "paid in instalments", payments: []Payment{pay("2026-03-12", 1000), pay("2026-03-05", 800)},
+			today: "2026-03-20", state: PaymentPaid, paid: Cents(1800), paidOn: "2026-03-12"},
+		{name: "paid late", payments: []Payment{pay("2026-04-02", 1800)},
+			today: "2026-04-10", state: PaymentPaid, paid: Cents(1800), paidOn: "2026-04-02"},
+		{name: "overpaid", payments: []Payment{pay("2026-03-05", 1500), pay("2026-03-06", 500)},
+			today: "2026-03-20", state: PaymentPaid, paid: Cents(2000), paidOn: "2026-03-06"},
+		{name: "a cent short is paid", payments: []Payment{pay("2026-03-05", 1799.99)},
+			today: "2026-03-20", state: PaymentPaid, paid: Cents(1799.99), paidOn: "2026-03-05"},
+		{name: "payments of other invoices", today: "2026-03-20", state: PaymentOverdue,
+			payments: []Payment{{Date: "2026-03-05", Amount: Cents(1800), InvoiceID: 2},
+				{Date: "2026-03-05", Amount: Cents(1800), ProjectID: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLedger([]Invoice{testInvoice}, tt.payments)
+			if got := l.InvoiceState(testInvoice, tt.today); got != tt.state {
+				t.Errorf("state %q, want %q", got, tt.state)
+			}
+			if got := l.InvoicePaid(testInvoice.ID); got != tt.paid {
+				t.Errorf("paid %s, want %s", got.Format("USD"), tt.paid.Format("USD"))
+			}
+			paidOn, ok := l.PaidOn(testInvoice)
+			if paidOn != tt.paidOn || ok != (tt.paidOn != "") {
+				t.Errorf("paid on %q (%v), want %q", paidOn, ok, tt.paidOn)
+			}
+		})
+	}
+}
+
+func TestProjectState(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	website := Project{ID: 1, Status: "Active", Billing: BillingFixed, Cost: Cents(1000)}
+	finished := Project{ID: 3, Status: "Completed", Billing: BillingFixed, Cost: Cents(400), Deadline: "2026-03-01"}
+	tests := []struct {
+		name     string
+		project  Project
+		invoices []Invoice
+		payments []Payment
+		today    string
+		state    string
+		due      Money
+		paid     Money
+	}{
+		{name: "unbilled", project: website, today: "2026-03-10", state: PaymentUnbilled},
+		{name: "invoiced", project: website, invoices: []Invoice{testInvoice},
+			today: "2026-03-10", state: PaymentInvoiced, due: Cents(1200)},
+		{
+			// Two thirds of the invoice bill the website
+			name: "share of a partial invoice payment", project: website, invoices: []Invoice{testInvoice},
+			payments: []Payment{{Date: "2026-03-05", Amount: Cents(900), InvoiceID: 1}},
+			today:    "2026-03-10", state: PaymentPartial, due: Cents(1200), paid: Cents(600)},
+		{name: "overdue invoice", project: website, invoices: []Invoice{testInvoice},
+			payments: []Payment{{Date: "2026-03-05", Amount: Cents(900), InvoiceID: 1}},
+			today:    "2026-03-16", state: PaymentOverdue, due: Cents(1200), paid: Cents(600)},
+		{name: "invoice paid", project: website, invoices: []Invoice{testInvoice},
+			payments: []Payment{{Date: "2026-03-20", Amount: Cents(1800), InvoiceID: 1}},
+			today:    "2026-03-25", state: PaymentPaid, due: Cents(1200), paid: Cents(1200)},
+		{name: "overpaid directly", project: finished,
+			payments: []Payment{{Date: "2026-03-02", Amount: Cents(250), ProjectID: 3},
+				{Date: "2026-03-04", Amount: Cents(250), ProjectID: 3}},
+			today: "2026-03-10", state: PaymentPaid, due: Cents(400), paid: Cents(500)},
+		{name: "paid directly in part", project: finished,
+			payments: []Payment{{Date: "2026-03-02", Amount: Cents(100), ProjectID: 3}},
+			today:    "2026-03-10", state: PaymentPartial, due: Cents(400), paid: Cents(100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLedger(tt.invoices, tt.payments)
+			if got := l.ProjectState(tt.project, tt.today, now); got != tt.state {
+				t.Errorf("state %q, want %q", got, tt.state)
+			}
+			if got := l.ProjectDue(tt.project, now); got != tt.due {
+				t.Errorf("due %s, want %s", got.Format("USD"), tt.due.Format("USD"))
+			}
+			if got := l.ProjectPaid(tt.project.ID); got != tt.paid {
+				t.Errorf("paid %s, want %s", got.Format("USD"), tt.paid.Format("USD"))
+			}
+		})
+	}
+}
+
+func TestInvoicePaymentSharedBetweenProjects(t *testing.T) {
+	// 100.00 over three projects cannot be shared in whole cents
+	inv := Invoice{ID: 1, DueDate: "2026-03-15"}
+	for id := 1; id <= 3; id++ {
+		inv.Items = append(inv.Items, InvoiceItem{Quantity: 1, UnitPrice: Cents(100.0 / 3), ProjectID: id})
+	}
+	l := NewLedger([]Invoice{inv}, []Payment{{Date: "2026-03-05", Amount: inv.Total(), InvoiceID: 1}})
+
+	var total Money
+	for id := 1; id <= 3; id++ {
+		receipts := l.Receipts(id)
+		if len(receipts) != 1 || receipts[0].Date.Format("2006-01-02") != "2026-03-05" {
+			t.Fatalf("receipts of project %d: %+v", id, receipts)
+		}
+		total += receipts[0].Amount
+		if state := l.ProjectState(Project{ID: id, Billing: BillingFixed}, "2026-03-20", time.Now()); state != PaymentPaid {
+			t.Errorf("project %d is %q, want paid", id, state)
+		}
+	}
+	if diff := inv.Total() - total; diff < -1 || diff > 1 {
+		t.Errorf("shares add up to %s of %s", total.Format("USD"), inv.Total().Format("USD"))
+	}
+}
+
+func TestTaxReceived(t *testing.T) {
+	l := NewLedger([]Invoice{testInvoice}, []Payment{{Date: "2026-03-05", Amount: Cents(900), InvoiceID: 1}})
+	received := l.TaxReceived(testInvoice)
+	// Half of the invoice is paid, and with it half of its 300.00 of tax
+	if len(received) != 1 || received[0].Amount != Cents(150) {
+		t.Errorf("tax received %+v, want 150.00", received)
+	}
+}
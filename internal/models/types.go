@@ -1,34 +1,296 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // Task represents a single task in a project
 type Task struct {
-	ID            int       `json:"id"`
-	ProjectID     int       `json:"project_id"`
-	Title         string    `json:"title"`
-	Description   string    `json:"description"`
-	Deadline      string    `json:"deadline"`
-	Status        string    `json:"status"` // "Waiting", "In Progress", "Done"
-	CompletedDate string    `json:"completed_date,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int         `json:"id"`
+	ProjectID     int         `json:"project_id"`
+	Title         string      `json:"title"`
+	Description   string      `json:"description"`
+	Deadline      string      `json:"deadline"` // YYYY-MM-DD
+	Status        string      `json:"status"`   // "Waiting", "In Progress", "Done"
+	CompletedDate string      `json:"completed_date,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	DeletedAt     *time.Time  `json:"deleted_at,omitempty"` // set while the task is in the trash
+	TimeEntries   []TimeEntry `json:"time_entries,omitempty"`
+	// LastEntryID is the highest time entry ID handed out on the task so far,
+	// so that the IDs of deleted entries are never reused
+	LastEntryID int `json:"last_entry_id,omitempty"`
+}
+
+// TimeEntry is a stretch of time worked on a task
+type TimeEntry struct {
+	ID    int        `json:"id"`
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"` // nil while the timer is running
+	Note  string     `json:"note,omitempty"`
+	// InvoiceID is the invoice the entry was billed on, 0 while unbilled
+	InvoiceID int `json:"invoice_id,omitempty"`
+}
+
+// Running reports whether the timer of the entry has not been stopped yet
+func (e TimeEntry) Running() bool {
+	return e.End == nil
+}
+
+// Duration returns the time covered by the entry, counting a running entry up to now
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	if e.End == nil {
+		return now.Sub(e.Start)
+	}
+	return e.End.Sub(e.Start)
+}
+
+// RunningEntry returns the entry whose timer is running, if any
+func (t Task) RunningEntry() (TimeEntry, bool) {
+	for _, e := range t.TimeEntries {
+		if e.Running() {
+			return e, true
+		}
+	}
+	return TimeEntry{}, false
+}
+
+// TrackedTime returns the total time recorded on the task
+func (t Task) TrackedTime(now time.Time) time.Duration {
+	var total time.Duration
+	for _, e := range t.TimeEntries {
+		total += e.Duration(now)
+	}
+	return total
 }
 
 // Project represents a freelance project
 type Project struct {
-	ID       int       `json:"id"`
-	Name     string    `json:"name"`
-	Client   string    `json:"client"`
-	Cost     float64   `json:"cost"`
-	Deadline string    `json:"deadline"`
-	Status   string    `json:"status"`
-	Tasks    []Task    `json:"tasks"`
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Client     string     `json:"client"`
+	ClientID   int        `json:"client_id,omitempty"` // 0 for projects without a client
+	Cost       Money      `json:"cost"`                // price of a fixed price project
+	Billing    string     `json:"billing"`             // "fixed", "hourly" or "daily"
+	Rate       Money      `json:"rate"`                // price per hour or day of an hourly or daily project
+	Currency   string     `json:"currency"`            // ISO code of the currency of cost and rate
+	Deadline   string     `json:"deadline"`            // YYYY-MM-DD
+	Status     string     `json:"status"`
+	Tasks      []Task     `json:"tasks"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"` // set while the project is archived
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`  // set while the project is in the trash
+	// InvoiceID is the invoice a fixed price project was billed on, 0 while unbilled
+	InvoiceID int `json:"invoice_id,omitempty"`
+	// LastTaskID is the highest task ID handed out in the project so far, so
+	// that the IDs of purged tasks are never reused
+	LastTaskID int `json:"last_task_id,omitempty"`
+}
+
+// Earning is income from a project attributed to the day it was earned
+type Earning struct {
+	Date   time.Time
+	Amount Money
+}
+
+// Earnings returns the income of the project by the day it was earned.
+// A fixed price project earns its cost on the deadline once it is completed;
+// hourly and daily projects earn their rate for every hour or day of tracked
+// time, on the day the work was done
+func (p Project) Earnings(now time.Time) []Earning {
+	var earnings []Earning
+	switch p.Billing {
+	case BillingHourly:
+		for _, t := range p.Tasks {
+			for _, e := range t.TimeEntries {
+				earnings = append(earnings, Earning{Date: e.Start, Amount: p.Rate.Times(e.Duration(now).Hours())})
+			}
+		}
+	case BillingDaily:
+		days := make(map[string]bool)
+		for _, t := range p.Tasks {
+			for _, e := range t.TimeEntries {
+				day := e.Start.Local().Format("2006-01-02")
+				if !days[day] {
+					days[day] = true
+					earnings = append(earnings, Earning{Date: e.Start, Amount: p.Rate})
+				}
+			}
+		}
+	default:
+		if p.Status != "Completed" {
+			return nil
+		}
+		deadline, err := time.ParseInLocation("2006-01-02", p.Deadline, time.Local)
+		if err != nil {
+			return nil
+		}
+		earnings = append(earnings, Earning{Date: deadline, Amount: p.Cost})
+	}
+	sort.Slice(earnings, func(i, j int) bool { return earnings[i].Date.Before(earnings[j].Date) })
+	return earnings
+}
+
+// paceDays is the number of days over which the recent pace of an hourly or
+// daily project is measured
+const paceDays = 28
+
+// Forecast returns the income an active, unarchived project is still
+// expected to earn. A fixed price project earns its cost on the deadline, or
+// today once the deadline has passed. An hourly or daily project goes on
+// earning at its pace of the last four weeks every day until its deadline;
+// without a deadline ahead or recent work it is not forecast, as reported by
+// Unforecast
+func (p Project) Forecast(now time.Time) []Earning {
+	if p.Status != "Active" || p.ArchivedAt != nil {
+		return nil
+	}
+	deadline, err := time.ParseInLocation("2006-01-02", p.Deadline, time.Local)
+	if err != nil {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if p.Billing == BillingHourly || p.Billing == BillingDaily {
+		return p.paceForecast(today, deadline, now)
+	}
+	if deadline.Before(today) {
+		deadline = today
+	}
+	return []Earning{{Date: deadline, Amount: p.Cost}}
+}
+
+// paceForecast spreads what the project earned in the paceDays up to now
+// evenly over the days after today up to the deadline. The amount of each
+// day is rounded so the days add up to whole cents
+func (p Project) paceForecast(today, deadline, now time.Time) []Earning {
+	since := today.AddDate(0, 0, -paceDays)
+	var recent Money
+	for _, e := range p.Earnings(now) {
+		if !e.Date.Before(since) {
+			recent += e.Amount
+		}
+	}
+	if recent <= 0 {
+		return nil
+	}
+	var forecast []Earning
+	var total Money
+	for day, i := today.AddDate(0, 0, 1), int64(1); !day.After(deadline); day, i = day.AddDate(0, 0, 1), i+1 {
+		amount := recent*Money(i)/paceDays - total
+		total += amount
+		forecast = append(forecast, Earning{Date: day, Amount: amount})
+	}
+	return forecast
+}
+
+// Unforecast reports whether an active hourly or daily project is left out
+// of the forecast because it has no deadline ahead or no work tracked in the
+// last four weeks, so the forecast understates what is still to come
+func (p Project) Unforecast(now time.Time) bool {
+	return (p.Billing == BillingHourly || p.Billing == BillingDaily) &&
+		p.Status == "Active" && p.ArchivedAt == nil && len(p.Forecast(now)) == 0
+}
+
+// Completion returns the share of the project's tasks that are done, or 1
+// for a project without tasks
+func (p Project) Completion() float64 {
+	if len(p.Tasks) == 0 {
+		return 1
+	}
+	done := 0
+	for _, t := range p.Tasks {
+		if t.Status == TaskStatusDone {
+			done++
+		}
+	}
+	return float64(done) / float64(len(p.Tasks))
+}
+
+// Income returns the total income of the project so far
+func (p Project) Income(now time.Time) Money {
+	var total Money
+	for _, e := range p.Earnings(now) {
+		total += e.Amount
+	}
+	return total
+}
+
+// TrackedTime returns the total time recorded on the tasks of the project
+func (p Project) TrackedTime(now time.Time) time.Duration {
+	var total time.Duration
+	for _, t := range p.Tasks {
+		total += t.TrackedTime(now)
+	}
+	return total
+}
+
+// Invoice is a bill for a client with numbered line items
+type Invoice struct {
+	ID        int           `json:"id"`
+	Number    string        `json:"number"`
+	Client    string        `json:"client"`
+	IssueDate string        `json:"issue_date"` // YYYY-MM-DD
+	DueDate   string        `json:"due_date"`   // YYYY-MM-DD
+	TaxRate   float64       `json:"tax_rate"`   // percent
+	Currency  string        `json:"currency"`   // ISO code, the currency of the projects billed
+	Items     []InvoiceItem `json:"items"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// InvoiceItem is a line of an invoice. It refers back to the work it bills
+// so that the same work is not invoiced twice
+type InvoiceItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit,omitempty"` // "h", "days" or empty for a fixed price
+	UnitPrice   Money   `json:"unit_price"`
+
+	ProjectID int            `json:"project_id"`
+	Entries   []TimeEntryRef `json:"entries,omitempty"` // empty when the item bills a fixed price project
+}
+
+// TimeEntryRef identifies a time entry of a task
+type TimeEntryRef struct {
+	TaskID  int `json:"task_id"`
+	EntryID int `json:"entry_id"`
+}
+
+// Amount returns the price of the line before tax
+func (item InvoiceItem) Amount() Money {
+	return item.UnitPrice.Times(item.Quantity)
 }
 
+// Subtotal returns the invoice total before tax
+func (inv Invoice) Subtotal() Money {
+	var total Money
+	for _, item := range inv.Items {
+		total += item.Amount()
+	}
+	return total
+}
+
+// Tax returns the tax added to the subtotal
+func (inv Invoice) Tax() Money {
+	return inv.Subtotal().Times(inv.TaxRate / 100)
+}
+
+// Total returns the amount due
+func (inv Invoice) Total() Money {
+	return inv.Subtotal() + inv.Tax()
+}
+
+// Project billing modes
+const (
+	BillingFixed  = "fixed"
+	BillingHourly = "hourly"
+	BillingDaily  = "daily"
+)
+
 // Task status constants
 const (
 	TaskStatusWaiting    = "Waiting"
 	TaskStatusInProgress = "In Progress"
 	TaskStatusDone       = "Done"
-) 
\ No newline at end of file
+)
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+	archived := now.AddDate(0, 0, -1)
+	// work tracks hours of work on each of the days before now
+	work := func(hours int, daysAgo ...int) []Task {
+		var entries []TimeEntry
+		for _, d := range daysAgo {
+			start := now.AddDate(0, 0, -d)
+			end := start.Add(time.Duration(hours) * time.Hour)
+			entries = append(entries, TimeEntry{Start: start, End: &end})
+		}
+		return []Task{{ID: 1, TimeEntries: entries}}
+	}
+
+	tests := []struct {
+		name       string
+		project    Project
+		days       int // number of days forecast
+		first      time.Time
+		total      Money // sum of the forecast
+		unforecast bool
+	}{
+		{name: "fixed price on its deadline",
+			project: Project{Status: "Active", Billing: BillingFixed, Cost: Cents(4000), Deadline: "2026-05-10"},
+			days:    1, first: time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local), total: Cents(4000)},
+		{name: "fixed price past its deadline counts today",
+			project: Project{Status: "Active", Billing: BillingFixed, Cost: Cents(100), Deadline: "2026-01-10"},
+			days:    1, first: today, total: Cents(100)},
+		{name: "fixed price without a deadline",
+			project: Project{Status: "Active", Billing: BillingFixed, Cost: Cents(100)}},
+		{name: "completed",
+			project: Project{Status: "Completed", Billing: BillingFixed, Cost: Cents(100), Deadline: "2026-05-10"}},
+		{name: "archived",
+			project: Project{Status: "Active", Billing: BillingHourly, Rate: Cents(50), Deadline: "2026-05-10",
+				ArchivedAt: &archived, Tasks: work(2, 3)}},
+		{
+			// 4 days of 7 hours at 50 in four weeks is 1400, or 50 a day
+			name: "hourly at its recent pace until the deadline",
+			project: Project{Status: "Active", Billing: BillingHourly, Rate: Cents(50), Deadline: "2026-03-20",
+				Tasks: work(7, 1, 2, 20, 27, 40)},
+			days: 10, first: today.AddDate(0, 0, 1), total: Cents(500)},
+		{
+			// 3 days in four weeks at 300 is 900, and 7/28 of it is 225
+			name: "daily at its recent pace until the deadline",
+			project: Project{Status: "Active", Billing: BillingDaily, Rate: Cents(300), Deadline: "2026-03-17",
+				Tasks: work(2, 1, 5, 6, 60)},
+			days: 7, first: today.AddDate(0, 0, 1), total: Cents(225)},
+		{name: "hourly without a deadline",
+			project:    Project{Status: "Active", Billing: BillingHourly, Rate: Cents(50), Tasks: work(2, 1)},
+			unforecast: true},
+		{name: "hourly past its deadline",
+			project: Project{Status: "Active", Billing: BillingHourly, Rate: Cents(50), Deadline: "2026-03-10",
+				Tasks: work(2, 1)},
+			unforecast: true},
+		{name: "hourly without recent work",
+			project: Project{Status: "Active", Billing: BillingHourly, Rate: Cents(50), Deadline: "2026-04-01",
+				Tasks: work(2, 29, 45)},
+			unforecast: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := tt.project.Forecast(now)
+			if len(forecast) != tt.days {
+				t.Fatalf("forecast over %d days, want %d: %+v", len(forecast), tt.days, forecast)
+			}
+			var total Money
+			for i, e := range forecast {
+				if want := tt.first.AddDate(0, 0, i); !e.Date.Equal(want) {
+					t.Errorf("earning %d on %v, want %v", i, e.Date, want)
+				}
+				total += e.Amount
+			}
+			if total != tt.total {
+				t.Errorf("forecast %s, want %s", total.Format("USD"), tt.total.Format("USD"))
+			}
+			if got := tt.project.Unforecast(now); got != tt.unforecast {
+				t.Errorf("Unforecast = %v, want %v", got, tt.unforecast)
+			}
+		})
+	}
+}
+
+func TestForecastAddsUpToWholeCents(t *testing.T) {
+	// 10.00 over four weeks does not divide into whole cents a day
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	end := now.Add(-time.Hour)
+	p := Project{Status: "Active", Billing: BillingHourly, Rate: Cents(10), Deadline: "2026-04-07",
+		Tasks: []Task{{TimeEntries: []TimeEntry{{Start: end.Add(-time.Hour), End: &end}}}}}
+
+	var total Money
+	for _, e := range p.Forecast(now) {
+		if e.Amount < 35 || e.Amount > 36 {
+			t.Errorf("%v forecast %d cents, want 35 or 36", e.Date, e.Amount)
+		}
+		total += e.Amount
+	}
+	if total != Cents(10) {
+		t.Errorf("28 days forecast %s, want the 10.00 of the last 28", total.Format("USD"))
+	}
+}
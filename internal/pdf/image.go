@@ -0,0 +1,80 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+// Image is a picture embedded in a document
+type Image struct {
+	name   string
+	Width  int // in pixels
+	Height int
+
+	data       []byte
+	filter     string
+	colorSpace string
+}
+
+// AddImage embeds a PNG or JPEG file in the document. JPEG files are stored
+// as they are; other images are stored losslessly, with any transparency
+// flattened onto a white background
+func (d *Document) AddImage(path string) (*Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &Image{name: fmt.Sprintf("Im%d", len(d.images)+1)}
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && format == "jpeg" &&
+		(cfg.ColorModel == color.YCbCrModel || cfg.ColorModel == color.GrayModel) {
+		img.Width, img.Height = cfg.Width, cfg.Height
+		img.data, img.filter = data, "DCTDecode"
+		img.colorSpace = "DeviceRGB"
+		if cfg.ColorModel == color.GrayModel {
+			img.colorSpace = "DeviceGray"
+		}
+		d.images = append(d.images, img)
+		return img, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	bounds := decoded.Bounds()
+	img.Width, img.Height = bounds.Dx(), bounds.Dy()
+
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	row := make([]byte, 0, 3*img.Width)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			// Colors are premultiplied by alpha; add white where the pixel is transparent
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+		zw.Write(row)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	img.data, img.filter, img.colorSpace = pixels.Bytes(), "FlateDecode", "DeviceRGB"
+
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// object returns the image XObject
+func (img *Image) object() []byte {
+	return stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s ",
+		img.Width, img.Height, img.colorSpace, img.filter), img.data)
+}
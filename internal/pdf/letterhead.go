@@ -0,0 +1,69 @@
+package pdf
+
+import "strings"
+
+// Margin is the space left around the content of a page, in points
+const Margin = 50
+
+// Letterhead holds the sender details printed at the top of invoices and reports
+type Letterhead struct {
+	Name    string
+	Address string // lines separated by newlines
+	TaxID   string
+	Bank    string // lines separated by newlines
+	Logo    string // path to a PNG or JPEG image
+}
+
+// Lines splits a multi-line detail such as the address into its non-empty lines
+func Lines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// DrawLetterhead prints the logo on the left and the sender details on the
+// right of the top of page, followed by a rule, and returns the y position
+// below it
+func (d *Document) DrawLetterhead(page *Page, lh Letterhead) (float64, error) {
+	bottom := float64(Margin)
+
+	if lh.Logo != "" {
+		img, err := d.AddImage(lh.Logo)
+		if err != nil {
+			return 0, err
+		}
+		// Fit the logo into 150x60 points, keeping its proportions
+		w, h := 150.0, 150.0*float64(img.Height)/float64(img.Width)
+		if h > 60 {
+			w, h = 60*float64(img.Width)/float64(img.Height), 60
+		}
+		page.Image(img, Margin, Margin, w, h)
+		bottom = Margin + h
+	}
+
+	right := PageWidth - Margin
+	y := float64(Margin) + 12
+	if lh.Name != "" {
+		page.TextRight(right, y, 14, true, lh.Name)
+		y += 16
+	}
+	details := Lines(lh.Address)
+	if lh.TaxID != "" {
+		details = append(details, "Tax ID: "+lh.TaxID)
+	}
+	for _, line := range details {
+		page.TextRight(right, y, 9, false, line)
+		y += 12
+	}
+	if y-9 > bottom {
+		bottom = y - 9
+	}
+
+	bottom += 12
+	page.Line(Margin, bottom, right, bottom, 0.5)
+	return bottom + 30, nil
+}
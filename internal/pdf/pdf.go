@@ -0,0 +1,206 @@
+// Package pdf writes simple PDF documents without external tools: text in the
+// standard Helvetica fonts, lines, filled rectangles and PNG or JPEG images.
+// Page content is left uncompressed so the text can be read back from the file
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a PDF being built page by page
+type Document struct {
+	title  string
+	pages  []*Page
+	images []*Image
+}
+
+// Page is a page of a document. Positions are given in points from the top
+// left corner of the page, y growing downwards
+type Page struct {
+	content bytes.Buffer
+}
+
+// New creates an empty document with the given title
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage appends a blank A4 page and returns it
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text draws s with its baseline at y, starting at x
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, num(size), num(x), num(PageHeight-y), escape(encode(s)))
+}
+
+// TextRight draws s so that it ends at x
+func (p *Page) TextRight(x, y, size float64, bold bool, s string) {
+	p.Text(x-TextWidth(s, size, bold), y, size, bold, s)
+}
+
+// Line draws a line of the given width between two points
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Rect fills a rectangle with a shade of gray from 0 (black) to 1 (white)
+func (p *Page) Rect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n",
+		num(gray), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Gray sets the shade used for the text drawn afterwards
+func (p *Page) Gray(gray float64) {
+	fmt.Fprintf(&p.content, "%s g\n", num(gray))
+}
+
+// Image draws an image added to the document with its top left corner at x, y
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		num(w), num(h), num(x), num(PageHeight-y-h), img.name)
+}
+
+// WriteFile writes the document to path
+func (d *Document) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Write writes the document in PDF format
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts, 5 is the document
+	// information, followed by the images and the pages with their contents
+	var objects [][]byte
+	add := func(obj []byte) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+
+	firstImage := 6
+	firstPage := firstImage + len(d.images)
+	var kids, xobjects []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	for i, img := range d.images {
+		xobjects = append(xobjects, fmt.Sprintf("/%s %d 0 R", img.name, firstImage+i))
+	}
+
+	add([]byte("<< /Type /Catalog /Pages 2 0 R >>"))
+	add([]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))))
+	add([]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"))
+	add([]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>"))
+	add([]byte(fmt.Sprintf("<< /Title (%s) /Producer (freelancy) >>", escape(encode(d.title)))))
+	for _, img := range d.images {
+		add(img.object())
+	}
+
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+	for i, p := range d.pages {
+		add([]byte(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources, firstPage+2*i+1)))
+		add(stream("", p.content.Bytes()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// stream builds a stream object with extra dictionary entries
+func stream(dict string, data []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<< %s/Length %d >>\nstream\n", dict, len(data))
+	b.Write(data)
+	b.WriteString("\nendstream")
+	return b.Bytes()
+}
+
+// encode converts text to the WinAnsi encoding of the standard fonts,
+// replacing characters it cannot represent with a question mark
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			b = '?'
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// escape quotes encoded text for use in a PDF string literal
+func escape(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			s.WriteByte('\\')
+			s.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&s, "\\%03o", c)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String()
+}
+
+// num formats a coordinate or size without needless decimals
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+// Package tax estimates the income tax and VAT to set aside from monthly
+// profits and the tax collected on invoices
+package tax
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Rules are the income tax rules of the config: progressive brackets for the
+// years that have them, and a flat rate for all other years
+type Rules struct {
+	// Rate is the flat percentage of the profit of a year without brackets
+	Rate float64 `json:"rate"`
+	// Brackets holds progressive brackets by year ("2026"). A year without
+	// brackets uses those of the latest earlier year that has them
+	Brackets map[string][]Bracket `json:"brackets"`
+}
+
+// Bracket taxes the part of a year's profit from From up to the next
+// bracket at Rate percent
+type Bracket struct {
+	From float64 `json:"from"`
+	Rate float64 `json:"rate"`
+}
+
+// Validate checks that rates are percentages and that the brackets of each
+// year start at increasing amounts
+func (r Rules) Validate() error {
+	if r.Rate < 0 || r.Rate > 100 {
+		return fmt.Errorf("rate must be a percentage between 0 and 100")
+	}
+	for year, brackets := range r.Brackets {
+		if _, err := strconv.Atoi(year); err != nil {
+			return fmt.Errorf("brackets: %q is not a year", year)
+		}
+		for i, b := range brackets {
+			if b.Rate < 0 || b.Rate > 100 {
+				return fmt.Errorf("brackets of %s: rate must be a percentage between 0 and 100", year)
+			}
+			if b.From < 0 || (i > 0 && b.From <= brackets[i-1].From) {
+				return fmt.Errorf("brackets of %s: amounts must start at 0 or more and increase", year)
+			}
+		}
+	}
+	return nil
+}
+
+// brackets returns the brackets that apply in year, or nil when the flat
+// rate does
+func (r Rules) brackets(year int) []Bracket {
+	best := 0
+	for key := range r.Brackets {
+		if y, err := strconv.Atoi(key); err == nil && y <= year && y > best && len(r.Brackets[key]) > 0 {
+			best = y
+		}
+	}
+	if best == 0 {
+		return nil
+	}
+	return r.Brackets[strconv.Itoa(best)]
+}
+
+// Describe tells which rules apply in year
+func (r Rules) Describe(year int) string {
+	brackets := r.brackets(year)
+	if brackets == nil {
+		return fmt.Sprintf("flat %g%%", r.Rate)
+	}
+	return fmt.Sprintf("%d brackets from %g%% to %g%%", len(brackets), brackets[0].Rate, brackets[len(brackets)-1].Rate)
+}
+
+// Owed returns the income tax on the profit of a year. There is no tax on
+// losses
+func (r Rules) Owed(year int, profit float64) float64 {
+	if profit <= 0 {
+		return 0
+	}
+	brackets := r.brackets(year)
+	if brackets == nil {
+		return profit * r.Rate / 100
+	}
+	var owed float64
+	for i, b := range brackets {
+		upper := profit
+		if i+1 < len(brackets) && brackets[i+1].From < profit {
+			upper = brackets[i+1].From
+		}
+		if upper > b.From {
+			owed += (upper - b.From) * b.Rate / 100
+		}
+	}
+	return owed
+}
+
+// Month is the profit of a month and the VAT collected in it
+type Month struct {
+	Start  time.Time // first day of the month
+	Profit float64
+	VAT    float64
+}
+
+// Liability is the tax to set aside for a month, a quarter or a year
+type Liability struct {
+	Period    string    // "Jan 2026", "Q1 2026" or "2026 to date"
+	Start     time.Time // first day of the period
+	Profit    float64
+	IncomeTax float64
+	VAT       float64
+}
+
+// SetAside returns the income tax and VAT of the period together
+func (l Liability) SetAside() float64 {
+	return l.IncomeTax + l.VAT
+}
+
+// add counts another period into l
+func (l *Liability) add(other Liability) {
+	l.Profit += other.Profit
+	l.IncomeTax += other.IncomeTax
+	l.VAT += other.VAT
+}
+
+// Estimate returns the liability of each of months, which are oldest first.
+// The income tax of a month is what its profit adds to the tax on the profit
+// of its year so far: a month moving the year into a higher bracket is taxed
+// at the higher rate, and a loss gives back tax set aside earlier in the
+// year. A year is counted from its first month given, so months should
+// start in January for the tax of the first year to be right
+func Estimate(rules Rules, months []Month) []Liability {
+	profits := make(map[int]float64)
+	liabilities := make([]Liability, len(months))
+	for i, mo := range months {
+		year := mo.Start.Year()
+		before := rules.Owed(year, profits[year])
+		profits[year] += mo.Profit
+		liabilities[i] = Liability{
+			Period:    mo.Start.Format("Jan 2006"),
+			Start:     mo.Start,
+			Profit:    mo.Profit,
+			IncomeTax: rules.Owed(year, profits[year]) - before,
+			VAT:       mo.VAT,
+		}
+	}
+	return liabilities
+}
+
+// Quarters adds up monthly liabilities, oldest first, by calendar quarter
+func Quarters(months []Liability) []Liability {
+	var quarters []Liability
+	for _, mo := range months {
+		quarter := (int(mo.Start.Month())-1)/3 + 1
+		period := fmt.Sprintf("Q%d %d", quarter, mo.Start.Year())
+		if len(quarters) == 0 || quarters[len(quarters)-1].Period != period {
+			start := time.Date(mo.Start.Year(), time.Month(quarter*3-2), 1, 0, 0, 0, 0, mo.Start.Location())
+			quarters = append(quarters, Liability{Period: period, Start: start})
+		}
+		quarters[len(quarters)-1].add(mo)
+	}
+	return quarters
+}
+
+// YearToDate adds up the monthly liabilities in the year of now, up to and
+// including its month
+func YearToDate(months []Liability, now time.Time) Liability {
+	total := Liability{
+		Period: fmt.Sprintf("%d to date", now.Year()),
+		Start:  time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()),
+	}
+	for _, mo := range months {
+		if mo.Start.Year() == now.Year() && !mo.Start.After(now) {
+			total.add(mo)
+		}
+	}
+	return total
+}
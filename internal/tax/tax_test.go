@@ -0,0 +1,138 @@
+package tax
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// progressive taxes the first 10000 of a year at 10% and the rest at 30%
+// from 2025 on, and every earlier year at a flat 20%
+var progressive = Rules{
+	Rate:     20,
+	Brackets: map[string][]Bracket{"2025": {{From: 0, Rate: 10}, {From: 10000, Rate: 30}}},
+}
+
+// months returns a month of profit for each of profits from start on
+func months(start time.Time, profits ...float64) []Month {
+	var ms []Month
+	for i, p := range profits {
+		ms = append(ms, Month{Start: start.AddDate(0, i, 0), Profit: p})
+	}
+	return ms
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestOwed(t *testing.T) {
+	tests := []struct {
+		name   string
+		year   int
+		profit float64
+		want   float64
+	}{
+		{"loss", 2025, -500, 0},
+		{"first bracket", 2025, 8000, 800},
+		{"at the bracket", 2025, 10000, 1000},
+		{"crossing the bracket", 2025, 12000, 1000 + 600},
+		{"later year uses the latest brackets", 2027, 12000, 1600},
+		{"earlier year is flat", 2024, 12000, 2400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := progressive.Owed(tt.year, tt.profit); !near(got, tt.want) {
+				t.Errorf("Owed(%d, %g) = %g, want %g", tt.year, tt.profit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	jan2025 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		months []Month
+		want   []float64 // income tax of each month
+	}{
+		{
+			name:   "flat rate",
+			months: months(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1000, 2000),
+			want:   []float64{200, 400},
+		},
+		{
+			// 6000 + 6000 crosses 10000 in February: 4000 at 10% and 2000 at 30%
+			name:   "crossing a bracket",
+			months: months(jan2025, 6000, 6000, 1000),
+			want:   []float64{600, 400 + 600, 300},
+		},
+		{
+			name:   "a loss gives tax back",
+			months: months(jan2025, 12000, -4000),
+			want:   []float64{1600, -600 - 200},
+		},
+		{
+			// The brackets start again in January 2026, and the profit of
+			// 2025 before the window still counts for its later months
+			name: "window spanning two years",
+			months: months(jan2025,
+				1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000,
+				1000, 1000),
+			want: []float64{
+				100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 300, 300,
+				100, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Estimate(progressive, tt.months)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d liabilities, want %d", len(got), len(tt.want))
+			}
+			for i, l := range got {
+				if !near(l.IncomeTax, tt.want[i]) {
+					t.Errorf("%s: income tax %g, want %g", l.Period, l.IncomeTax, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateNeedsTheWholeYear(t *testing.T) {
+	// Twelve months from March 2025 to February 2026 with the months of 2025
+	// before the window left out understate the tax of late 2025
+	all := months(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 2000, 2000, 2000, 2000, 2000, 2000,
+		2000, 2000, 2000, 2000, 2000, 2000, 2000, 2000)
+	full := Estimate(progressive, all)[2:]
+	window := Estimate(progressive, all[2:])
+
+	var fullTax, windowTax float64
+	for i := range window {
+		fullTax += full[i].IncomeTax
+		windowTax += window[i].IncomeTax
+	}
+	// 2025: 24000 owes 1000 + 4200 = 5200, of which January and February
+	// owe 400. 2026: 4000 owes 400
+	if !near(fullTax, 5200-400+400) {
+		t.Errorf("tax over the window %g, want %g", fullTax, 5200.0)
+	}
+	if windowTax >= fullTax {
+		t.Errorf("leaving out January and February gave %g, expected less than %g", windowTax, fullTax)
+	}
+}
+
+func TestQuartersAndYearToDate(t *testing.T) {
+	liabilities := Estimate(progressive, months(time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), 1000, 1000, 1000, 1000))
+	quarters := Quarters(liabilities)
+	if len(quarters) != 2 || quarters[0].Period != "Q4 2025" || quarters[1].Period != "Q1 2026" {
+		t.Fatalf("quarters %+v", quarters)
+	}
+	if !near(quarters[0].Profit, 2000) || !near(quarters[1].IncomeTax, 200) {
+		t.Errorf("quarters %+v", quarters)
+	}
+	ytd := YearToDate(liabilities, time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC))
+	if ytd.Period != "2026 to date" || !near(ytd.Profit, 1000) {
+		t.Errorf("year to date %+v", ytd)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/invoice"
+	"freelancy.go/internal/models"
+	"freelancy.go/ui"
+)
+
+// InvoiceList holds the invoices shown in the invoices view and the invoice
+// being drafted in the new_invoice view
+type InvoiceList struct {
+	invoices []models.Invoice
+	cursor   int
+
+	// A new invoice is drafted in three steps: "client", "items" and "details".
+	// pick is the cursor in the client and item lists
+	step     string
+	pick     int
+	clients  []string
+	client   string
+	items    []models.InvoiceItem
+	selected []bool
+	form     ui.InvoiceForm
+	// currencies holds the currency of each project of the client by ID;
+	// an invoice bills a single currency
+	currencies map[int]string
+}
+
+func (m *model) updateInvoiceList() {
+	m.invoiceList.invoices = m.storage.GetInvoices()
+	if m.invoiceList.cursor >= len(m.invoiceList.invoices) {
+		m.invoiceList.cursor = len(m.invoiceList.invoices) - 1
+	}
+	if m.invoiceList.cursor < 0 {
+		m.invoiceList.cursor = 0
+	}
+}
+
+// updateInvoices handles keys in the invoices view
+func (m model) updateInvoices(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "I":
+		m.activeView = "projects"
+		m.refreshData()
+	case "up":
+		m.invoiceList.cursor--
+		if m.invoiceList.cursor < 0 {
+			m.invoiceList.cursor = len(m.invoiceList.invoices) - 1
+		}
+	case "down":
+		m.invoiceList.cursor++
+		if m.invoiceList.cursor >= len(m.invoiceList.invoices) {
+			m.invoiceList.cursor = 0
+		}
+	case "n":
+		m.invoiceList.clients = invoice.Clients(m.allProjects())
+		if len(m.invoiceList.clients) == 0 {
+			return m, m.status.Info("Nothing to invoice: no completed fixed price projects or unbilled tracked time")
+		}
+		m.invoiceList.step = "client"
+		m.invoiceList.pick = 0
+		m.activeView = "new_invoice"
+	case "x":
+		if len(m.invoiceList.invoices) == 0 {
+			return m, nil
+		}
+		cmd = m.writeInvoice(m.invoiceList.invoices[m.invoiceList.cursor], m.config.InvoiceDir)
+	case "p":
+		if len(m.invoiceList.invoices) == 0 {
+			return m, nil
+		}
+		return m.payInvoice(m.invoiceList.invoices[m.invoiceList.cursor])
+	}
+	return m, cmd
+}
+
+// updateNewInvoice walks through choosing the client, the items and the
+// details of a new invoice
+func (m model) updateNewInvoice(msg tea.Msg) (tea.Model, tea.Cmd) {
+	draft := &m.invoiceList
+	keyMsg, isKey := msg.(tea.KeyMsg)
+
+	switch draft.step {
+	case "client":
+		if !isKey {
+			return m, nil
+		}
+		switch keyMsg.String() {
+		case "esc":
+			m.activeView = "invoices"
+			m.updateInvoiceList()
+		case "up":
+			draft.pick = (draft.pick + len(draft.clients) - 1) % len(draft.clients)
+		case "down":
+			draft.pick = (draft.pick + 1) % len(draft.clients)
+		case "enter":
+			draft.client = draft.clients[draft.pick]
+			draft.items = invoice.ForClient(m.allProjects(), draft.client)
+			draft.currencies = make(map[int]string)
+			for _, p := range m.allProjects() {
+				draft.currencies[p.ID] = p.Currency
+			}
+			draft.selected = make([]bool, len(draft.items))
+			for i := range draft.selected {
+				draft.selected[i] = true
+			}
+			draft.pick = 0
+			draft.step = "items"
+		}
+
+	case "items":
+		if !isKey {
+			return m, nil
+		}
+		switch keyMsg.String() {
+		case "esc":
+			draft.pick = 0
+			draft.step = "client"
+		case "up":
+			draft.pick = (draft.pick + len(draft.items) - 1) % len(draft.items)
+		case "down":
+			draft.pick = (draft.pick + 1) % len(draft.items)
+		case " ":
+			draft.selected[draft.pick] = !draft.selected[draft.pick]
+		case "a":
+			all := len(draft.chosenItems()) < len(draft.items)
+			for i := range draft.selected {
+				draft.selected[i] = all
+			}
+		case "enter":
+			if len(draft.chosenItems()) == 0 {
+				return m, m.status.Info("Select at least one item with Space")
+			}
+			if currencies := draft.chosenCurrencies(); len(currencies) > 1 {
+				return m, m.status.Info(fmt.Sprintf("The items are billed in %s, invoice each currency separately",
+					strings.Join(currencies, " and ")))
+			}
+			now := time.Now()
+			draft.form = ui.NewInvoiceForm(
+				fmt.Sprintf("New invoice for %s, %s before tax", draft.client, draft.subtotal().Format(draft.currency())),
+				now.Format(ui.DateLayout),
+				now.AddDate(0, 0, m.config.PaymentTermDays).Format(ui.DateLayout),
+				m.config.TaxRate,
+				m.config.InvoiceDir,
+			)
+			draft.step = "details"
+			return m, draft.form.Init()
+		}
+
+	case "details":
+		if isKey && keyMsg.String() == "esc" {
+			draft.step = "items"
+			return m, nil
+		}
+		var cmd tea.Cmd
+		draft.form, cmd = draft.form.Update(msg)
+		if !draft.form.Done() {
+			return m, cmd
+		}
+
+		issueDate, dueDate, taxRate, dir := draft.form.GetValues()
+		issue := &history.AddInvoice{Invoice: models.Invoice{
+			Client:    draft.client,
+			IssueDate: issueDate,
+			DueDate:   dueDate,
+			TaxRate:   taxRate,
+			Currency:  draft.currency(),
+			Items:     draft.chosenItems(),
+		}}
+		m.activeView = "invoices"
+		if err := m.history.Execute(m.storage, issue); err != nil {
+			m.updateInvoiceList()
+			return m, m.status.Error(fmt.Sprintf("Could not %s: %v", issue.Description(), err))
+		}
+		m.refreshData()
+		m.invoiceList.cursor = max(len(m.invoiceList.invoices)-1, 0)
+		return m, m.writeInvoice(issue.Invoice, dir)
+	}
+
+	return m, nil
+}
+
+// writeInvoice renders an invoice into dir and reports where it was written
+func (m *model) writeInvoice(inv models.Invoice, dir string) tea.Cmd {
+	if _, err := invoice.Write(inv, dir, m.letterhead()); err != nil {
+		return m.status.Error(fmt.Sprintf("Could not write invoice %s: %v", inv.Number, err))
+	}
+	return m.status.Success(fmt.Sprintf("Invoice %s written to %s (%s)",
+		inv.Number, dir, strings.Join(invoice.Formats, ", ")))
+}
+
+// chosenItems returns the items ticked in the draft invoice
+func (l InvoiceList) chosenItems() []models.InvoiceItem {
+	var items []models.InvoiceItem
+	for i, item := range l.items {
+		if l.selected[i] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// chosenCurrencies returns the currencies of the ticked items, sorted
+func (l InvoiceList) chosenCurrencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, item := range l.chosenItems() {
+		if currency := l.itemCurrency(item); !seen[currency] {
+			seen[currency] = true
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// itemCurrency returns the currency of the project an item bills
+func (l InvoiceList) itemCurrency(item models.InvoiceItem) string {
+	if currency := l.currencies[item.ProjectID]; currency != "" {
+		return currency
+	}
+	return models.DefaultCurrency
+}
+
+// currency returns the currency of the draft invoice, that of its first
+// ticked item
+func (l InvoiceList) currency() string {
+	if currencies := l.chosenCurrencies(); len(currencies) > 0 {
+		return currencies[0]
+	}
+	return models.DefaultCurrency
+}
+
+// subtotal returns the amount of the ticked items before tax
+func (l InvoiceList) subtotal() models.Money {
+	return models.Invoice{Items: l.chosenItems()}.Subtotal()
+}
+
+func (m model) renderInvoices() string {
+	s := "Invoices (N: new, X: write files, P: record payment, U/Ctrl+R: undo/redo, ↑/↓: select, Shift+L: messages, ESC: back, Q: quit)\n\n"
+
+	if len(m.invoiceList.invoices) == 0 {
+		return s + "No invoices yet\n"
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	rowStyle := lipgloss.NewStyle()
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	today := time.Now().Format(ui.DateLayout)
+	row := "%-10s %-24.24s %-10s  %-10s  %12s  %12s  "
+	s += headerStyle.Render(fmt.Sprintf(row+"%s", "Number", "Client", "Issued", "Due", "Total", "Paid", "Status")) + "\n"
+	for i, inv := range m.invoiceList.invoices {
+		style := rowStyle
+		if i == m.invoiceList.cursor {
+			style = selectedStyle
+		}
+		state := m.ledger.InvoiceState(inv, today)
+		s += style.Render(fmt.Sprintf(row, inv.Number, inv.Client, inv.IssueDate, inv.DueDate,
+			inv.Total().Format(inv.Currency), m.ledger.InvoicePaid(inv.ID).Format(inv.Currency))) +
+			paymentStyle(state).Render(state) + "\n"
+	}
+
+	inv := m.invoiceList.invoices[m.invoiceList.cursor]
+	s += "\n" + headerStyle.Render(inv.Number+" for "+inv.Client) + "\n"
+	for _, item := range inv.Items {
+		s += fmt.Sprintf("  %-40.40s %10s x %10s = %12s\n", item.Description,
+			invoice.FormatQuantity(item), item.UnitPrice.Format(inv.Currency), item.Amount().Format(inv.Currency))
+	}
+	s += fmt.Sprintf("  Subtotal %s, tax %s (%g%%), total due %s\n",
+		inv.Subtotal().Format(inv.Currency), inv.Tax().Format(inv.Currency), inv.TaxRate, inv.Total().Format(inv.Currency))
+	for _, pay := range m.ledger.InvoicePayments(inv.ID) {
+		s += fmt.Sprintf("  Paid %s on %s", pay.Amount.Format(inv.Currency), pay.Date)
+		if pay.Method != "" {
+			s += " by " + pay.Method
+		}
+		s += "\n"
+	}
+	return s
+}
+
+func (m model) renderNewInvoice() string {
+	draft := m.invoiceList
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	switch draft.step {
+	case "client":
+		s := "New invoice: choose a client with unbilled work (↑/↓: select, Enter: next, ESC: back)\n\n"
+		for i, client := range draft.clients {
+			line := "  " + client
+			if i == draft.pick {
+				line = selectedStyle.Render("> " + client)
+			}
+			s += line + "\n"
+		}
+		return s
+
+	case "items":
+		s := fmt.Sprintf("New invoice for %s: choose what to bill (Space: toggle, A: all, Enter: next, ESC: back)\n\n", draft.client)
+		for i, item := range draft.items {
+			check := "[ ]"
+			if draft.selected[i] {
+				check = "[x]"
+			}
+			line := fmt.Sprintf("%s %-40.40s %10s  %12s", check, item.Description,
+				invoice.FormatQuantity(item), item.Amount().Format(draft.itemCurrency(item)))
+			if i == draft.pick {
+				line = selectedStyle.Render(line)
+			}
+			s += line + "\n"
+		}
+		total := draft.subtotal().Format(draft.currency()) + " before tax"
+		if currencies := draft.chosenCurrencies(); len(currencies) > 1 {
+			total = "billed in " + strings.Join(currencies, " and ")
+		}
+		s += fmt.Sprintf("\n%d of %d items, %s\n", len(draft.chosenItems()), len(draft.items), total)
+		return s
+	}
+
+	return draft.form.View()
+}
@@ -1,33 +1,80 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"freelancy.go/config"
+	"freelancy.go/internal/exchange"
+	"freelancy.go/internal/history"
 	"freelancy.go/internal/models"
 	"freelancy.go/storage"
 	"freelancy.go/ui"
 )
 
 type model struct {
-	storage     *storage.Storage
-	activeView  string // "projects", "tasks", "new_project", "new_task", "income"
+	storage     storage.Repository
+	history     *history.History
+	config      config.Config
+	activeView  string // "projects", "tasks", "new_project", "new_task", "edit_project", "edit_task", "income", "trash", "archive", "messages", "stop_timer", "invoices", "new_invoice", "new_payment", "clients", "new_client", "edit_client", "expenses", "new_expense", "edit_expense"
 	projectList ProjectList
 	taskTable   TaskTable
+	trashList   TrashList
+	archiveList ArchiveList
+	invoiceList InvoiceList
+	clientList  ClientList
+	expenseList ExpenseList
 	projectForm ui.ProjectForm
+	clientForm  ui.ClientForm
+	expenseForm ui.ExpenseForm
 	taskForm    ui.TaskForm
 	incomeChart ui.IncomeChart
+	status      ui.StatusBar
+	messageLog  MessageLog
+
+	// showTaxes shows the taxes to set aside under the income chart
+	showTaxes bool
+
+	// timerPrompt asks for a note on the time entry that stopping will close
+	timerPrompt ui.Prompt
+	stopping    *history.StopTimer
+
+	// paymentForm asks for the details of the payment recording will store,
+	// then returns to paymentReturn
+	paymentForm   ui.PaymentForm
+	recording     *history.RecordPayment
+	paymentReturn string
+
+	// ledger holds the invoices and payments behind the payment states
+	ledger models.Ledger
+	// rates convert amounts into the reporting currency of the config
+	rates exchange.Rates
+
+	// dialog asks for confirmation before pending is executed
+	dialog         ui.Confirm
+	pending        history.Command
+	pendingSuccess string
+
+	// The project, task, client or expense being edited in the edit_project,
+	// edit_task, edit_client and edit_expense views
+	editingProject models.Project
+	editingTask    models.Task
+	editingClient  models.Client
+	editingExpense models.Expense
 }
 
 type ProjectList struct {
-	projects  []models.Project
-	selected  int
-	style     lipgloss.Style
+	projects     []models.Project
+	selected     int
+	style        lipgloss.Style
+	showArchived bool
 }
 
 type TaskTable struct {
@@ -43,15 +90,11 @@ const (
 	TaskStatusDone       = models.TaskStatusDone
 )
 
-func initialModel() model {
-	storage, err := storage.NewStorage()
-	if err != nil {
-		fmt.Printf("Error initializing storage: %v\n", err)
-		os.Exit(1)
-	}
-
+func initialModel(storage storage.Repository, cfg config.Config) model {
 	return model{
 		storage:    storage,
+		history:    &history.History{},
+		config:     cfg,
 		activeView: "projects",
 		projectList: ProjectList{
 			projects: storage.GetProjects(),
@@ -62,59 +105,94 @@ func initialModel() model {
 				Padding(1),
 		},
 		taskTable: TaskTable{
-			tasks:   make([]models.Task, 0),
+			tasks:   storage.GetTasks(),
 			cursor:  0,
 			focused: "waiting",
 		},
-		projectForm: ui.NewProjectForm(),
-		taskForm:   ui.NewTaskForm(0),
-		incomeChart: ui.NewIncomeChart(),
+		ledger:      models.NewLedger(storage.GetInvoices(), storage.GetPayments()),
+		clientList:  ClientList{sortColumn: 1, descending: true},
+		projectForm: ui.NewProjectForm(nil, cfg.Currency),
+		taskForm:    ui.NewTaskForm(0),
+		incomeChart: ui.NewIncomeChart(func(amount float64) string {
+			return models.Cents(amount).Format(cfg.Currency)
+		}),
+		status: ui.NewStatusBar(),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	if watcher, ok := m.storage.(storage.Watcher); ok {
+		return tea.Batch(watcher.Watch(), tickTimer())
+	}
+	return tickTimer()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	m.status = m.status.Update(msg)
+
+	if _, ok := msg.(timerTickMsg); ok {
+		return m, tickTimer()
+	}
+
+	// Reads made while rendering the last frame may have failed
+	m.reportReadError()
+
+	// The income chart fits its bars to the width of the terminal
+	if sizeMsg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.incomeChart, cmd = m.incomeChart.Update(sizeMsg)
+		return m, cmd
+	}
+
+	// Reload data changed on disk by another process and keep watching
+	if _, ok := msg.(storage.DataChangedMsg); ok {
+		watcher := m.storage.(storage.Watcher)
+		if err := watcher.Reload(); err != nil {
+			cmd = m.status.Error(fmt.Sprintf("Could not reload data: %v", err))
+		} else {
+			cmd = m.status.Info("Loaded changes made outside this window")
+		}
+		m.refreshData()
+		return m, tea.Batch(cmd, watcher.Watch())
+	}
+
+	// An open confirmation dialog takes every key until it is answered
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.dialog.Open() && keyMsg.String() != "ctrl+c" {
+		var confirmed bool
+		m.dialog, confirmed = m.dialog.Update(keyMsg)
+		if confirmed {
+			cmd = m.execute(m.pending, m.pendingSuccess)
+			m.refreshData()
+		} else if !m.dialog.Open() {
+			cmd = m.status.Info("Cancelled")
+		}
+		if !m.dialog.Open() {
+			m.pending = nil
+		}
+		return m, cmd
+	}
+
 	// Handle common commands
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		switch keyMsg.String() {
 		case "q", "ctrl+c":
+			if keyMsg.String() == "q" && m.inForm() {
+				break
+			}
 			return m, tea.Quit
 		case "tab":
+			if m.inForm() {
+				break
+			}
 			switch m.activeView {
 			case "projects":
 				m.activeView = "tasks"
 				m.updateTaskTable()
 			case "tasks":
 				m.activeView = "income"
-				m.projectList.projects = m.storage.GetProjects()
-				var uiProjects []ui.Project
-				for _, p := range m.projectList.projects {
-					var uiTasks []ui.Task
-					for _, t := range p.Tasks {
-						uiTasks = append(uiTasks, ui.Task{
-							ID:          t.ID,
-							ProjectID:   t.ProjectID,
-							Title:       t.Title,
-							Description: t.Description,
-							Status:      t.Status,
-						})
-					}
-					uiProjects = append(uiProjects, ui.Project{
-						ID:       p.ID,
-						Name:     p.Name,
-						Client:   p.Client,
-						Cost:     p.Cost,
-						Deadline: p.Deadline,
-						Status:   p.Status,
-						Tasks:    uiTasks,
-					})
-				}
-				m.incomeChart.UpdateData(uiProjects)
+				m.loadProjects()
+				m.incomeChart.UpdateData(m.chartData())
 			case "income":
 				m.activeView = "projects"
 			}
@@ -122,7 +200,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "n":
 			if m.activeView == "projects" {
 				m.activeView = "new_project"
-				m.projectForm = ui.NewProjectForm()
+				m.projectForm = ui.NewProjectForm(m.uiClients(), m.config.Currency)
 				return m, nil
 			}
 		case "t":
@@ -131,6 +209,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.taskForm = ui.NewTaskForm(m.projectList.projects[m.projectList.selected].ID)
 				return m, nil
 			}
+		case "e":
+			if m.activeView == "projects" && len(m.projectList.projects) > 0 {
+				m.editingProject = m.projectList.projects[m.projectList.selected]
+				m.projectForm = ui.EditProjectForm(toUIProjects([]models.Project{m.editingProject})[0], m.uiClients(), m.config.Currency)
+				m.activeView = "edit_project"
+				return m, nil
+			} else if m.activeView == "tasks" {
+				if tasks := m.focusedTasks(); m.taskTable.cursor < len(tasks) {
+					m.editingTask = tasks[m.taskTable.cursor]
+					m.taskForm = ui.EditTaskForm(toUITask(m.editingTask))
+					m.activeView = "edit_task"
+				}
+				return m, nil
+			}
+		case " ":
+			if m.activeView == "tasks" {
+				if tasks := m.focusedTasks(); m.taskTable.cursor < len(tasks) {
+					return m.toggleTimer(tasks[m.taskTable.cursor])
+				}
+				return m, nil
+			}
 		case "s":
 			if m.activeView == "tasks" {
 				// Get current task
@@ -167,37 +266,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						completedDate = time.Now().Format("2006-01-02")
 					}
 
-					if err := m.storage.UpdateTaskStatus(currentTask.ProjectID, currentTask.ID, newStatus, completedDate); err != nil {
-						fmt.Printf("Error updating task status: %v\n", err)
-					}
+					cmd = m.execute(&history.SetTaskStatus{
+						Task:             *currentTask,
+						NewStatus:        newStatus,
+						NewCompletedDate: completedDate,
+					}, "Task moved to "+newStatus)
 					m.updateTaskTable()
 				}
-				return m, nil
+				return m, cmd
 			} else if m.activeView == "projects" && len(m.projectList.projects) > 0 {
 				project := m.projectList.projects[m.projectList.selected]
 				newStatus := "Completed"
 				if project.Status == "Completed" {
 					newStatus = "Active"
 				}
-				if err := m.storage.UpdateProjectStatus(project.ID, newStatus); err != nil {
-					fmt.Printf("Error updating project status: %v\n", err)
-				}
-				m.projectList.projects = m.storage.GetProjects()
-				return m, nil
+				cmd = m.execute(&history.SetProjectStatus{Project: project, NewStatus: newStatus}, "Project marked as "+newStatus)
+				m.loadProjects()
+				return m, cmd
 			}
 		case "d":
 			if m.activeView == "projects" && len(m.projectList.projects) > 0 {
 				project := m.projectList.projects[m.projectList.selected]
-				if err := m.storage.DeleteProject(project.ID); err != nil {
-					fmt.Printf("Error deleting project: %v\n", err)
-				}
-				m.projectList.projects = m.storage.GetProjects()
-				if m.projectList.selected >= len(m.projectList.projects) {
-					m.projectList.selected = len(m.projectList.projects) - 1
-				}
-				if m.projectList.selected < 0 {
-					m.projectList.selected = 0
-				}
+				m.confirm(projectQuestion("Delete", project), &history.DeleteProject{Project: project}, "Project moved to the trash")
 				return m, nil
 			} else if m.activeView == "tasks" {
 				// Get current task
@@ -218,17 +308,106 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				if currentTask != nil {
-					if err := m.storage.DeleteTask(currentTask.ProjectID, currentTask.ID); err != nil {
-						fmt.Printf("Error deleting task: %v\n", err)
-					}
-					m.updateTaskTable()
+					m.confirm(fmt.Sprintf("Delete task '%s'?", currentTask.Title),
+						&history.DeleteTask{Task: *currentTask}, "Task moved to the trash")
+				}
+				return m, nil
+			}
+		case "a":
+			if m.activeView == "projects" && len(m.projectList.projects) > 0 {
+				project := m.projectList.projects[m.projectList.selected]
+				success := "Project archived"
+				if project.ArchivedAt != nil {
+					success = "Project unarchived"
 				}
+				cmd = m.execute(&history.SetProjectArchived{Project: project, Archived: project.ArchivedAt == nil}, success)
+				m.refreshData()
+				return m, cmd
+			}
+		case "h":
+			if m.activeView == "projects" {
+				m.projectList.showArchived = !m.projectList.showArchived
+				m.refreshData()
+				return m, nil
+			}
+		case "A":
+			if m.activeView == "projects" {
+				m.activeView = "archive"
+				m.archiveList.cursor = 0
+				m.updateArchiveList()
+				return m, nil
+			}
+		case "I":
+			if m.activeView == "projects" {
+				m.activeView = "invoices"
+				m.updateInvoiceList()
+				m.invoiceList.cursor = max(len(m.invoiceList.invoices)-1, 0)
+				return m, nil
+			}
+		case "p":
+			if m.activeView == "projects" && len(m.projectList.projects) > 0 {
+				return m.payProject(m.projectList.projects[m.projectList.selected])
+			}
+		case "C":
+			if m.activeView == "projects" {
+				m.activeView = "clients"
+				m.clientList.cursor = 0
+				m.updateClientList()
+				return m, nil
+			}
+		case "E":
+			if m.activeView == "projects" {
+				m.activeView = "expenses"
+				m.updateExpenseList()
+				m.expenseList.cursor = max(len(m.expenseList.expenses)-1, 0)
+				return m, nil
+			}
+		case "b":
+			if m.activeView == "projects" || m.activeView == "tasks" {
+				m.activeView = "trash"
+				m.trashList.cursor = 0
+				m.updateTrashList()
+				return m, nil
+			}
+		case "L":
+			if m.activeView == "projects" || m.activeView == "tasks" || m.activeView == "income" ||
+				m.activeView == "trash" || m.activeView == "archive" || m.activeView == "invoices" ||
+				m.activeView == "clients" || m.activeView == "expenses" {
+				m.messageLog = MessageLog{returnView: m.activeView}
+				m.activeView = "messages"
 				return m, nil
 			}
+		case "u", "ctrl+r":
+			if m.activeView == "projects" || m.activeView == "tasks" || m.activeView == "income" ||
+				m.activeView == "trash" || m.activeView == "archive" || m.activeView == "invoices" ||
+				m.activeView == "clients" || m.activeView == "expenses" {
+				revert, verb, done := m.history.Undo, "undo", "Undone"
+				if keyMsg.String() == "ctrl+r" {
+					revert, verb, done = m.history.Redo, "redo", "Redone"
+				}
+				reverted, err := revert(m.storage)
+				switch {
+				case err != nil:
+					cmd = m.status.Error(fmt.Sprintf("Could not %s %s: %v", verb, reverted.Description(), err))
+				case reverted == nil:
+					cmd = m.status.Info("Nothing to " + verb)
+				default:
+					cmd = m.status.Success(fmt.Sprintf("%s: %s", done, reverted.Description()))
+				}
+				m.refreshData()
+				return m, cmd
+			}
 		case "esc":
-			if m.activeView == "new_project" || m.activeView == "new_task" {
+			if m.activeView == "new_project" || m.activeView == "new_task" || m.activeView == "edit_project" {
 				m.activeView = "projects"
 				return m, nil
+			} else if m.activeView == "edit_task" {
+				m.activeView = "tasks"
+				return m, nil
+			} else if m.activeView == "stop_timer" {
+				m.stopping = nil
+				m.activeView = "tasks"
+				return m, m.status.Info("The timer is still running")
 			}
 		case "up", "down", "left", "right":
 			if m.activeView == "projects" {
@@ -299,29 +478,69 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle view-specific updates
 	switch m.activeView {
 	case "income":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "x" {
+			return m, m.writeIncomeReport()
+		}
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "t" {
+			m.showTaxes = !m.showTaxes
+			return m, nil
+		}
 		m.incomeChart, cmd = m.incomeChart.Update(msg)
+	case "trash":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateTrash(keyMsg)
+		}
+	case "archive":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateArchive(keyMsg)
+		}
+	case "messages":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateMessageLog(keyMsg)
+		}
+	case "invoices":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateInvoices(keyMsg)
+		}
+	case "new_invoice":
+		return m.updateNewInvoice(msg)
+	case "new_payment":
+		return m.updatePayment(msg)
+	case "clients":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateClients(keyMsg)
+		}
+	case "new_client", "edit_client":
+		return m.updateClientForm(msg)
+	case "expenses":
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			return m.updateExpenses(keyMsg)
+		}
+	case "new_expense", "edit_expense":
+		return m.updateExpenseForm(msg)
+	case "stop_timer":
+		return m.updateStopTimer(msg)
 	case "new_project":
 		var formModel tea.Model
 		formModel, cmd = m.projectForm.Update(msg)
 		m.projectForm = formModel.(ui.ProjectForm)
 
 		if m.projectForm.Done() {
-			name, client, costStr, deadlineStr := m.projectForm.GetValues()
-			cost, _ := strconv.ParseFloat(costStr, 64)
+			name, client, billingStr, costStr, deadlineStr := m.projectForm.GetValues()
 			
 			newProject := models.Project{
 				Name:     name,
 				Client:   client,
-				Cost:     cost,
+				ClientID: m.projectForm.ClientID(),
+				Currency: m.formCurrency(),
 				Deadline: deadlineStr,
 				Tasks:    make([]models.Task, 0),
 			}
+			setPrice(&newProject, billingStr, costStr)
 			
-			if err := m.storage.AddProject(newProject); err != nil {
-				fmt.Printf("Error saving project: %v\n", err)
-			}
+			cmd = m.execute(&history.AddProject{Project: newProject}, "Project saved")
 			
-			m.projectList.projects = m.storage.GetProjects()
+			m.loadProjects()
 			m.activeView = "projects"
 		}
 	case "new_task":
@@ -334,41 +553,285 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			projectID := m.taskForm.GetProjectID()
 			
 			newTask := models.Task{
+				ProjectID:   projectID,
 				Title:       title,
 				Description: description,
 				Deadline:    deadline,
 				Status:      models.TaskStatusWaiting,
 			}
 			
-			if err := m.storage.AddTask(projectID, newTask); err != nil {
-				fmt.Printf("Error saving task: %v\n", err)
-			}
+			cmd = m.execute(&history.AddTask{Task: newTask}, "Task saved")
 			
-			m.projectList.projects = m.storage.GetProjects()
+			m.loadProjects()
 			m.updateTaskTable()
 			m.activeView = "projects"
 		}
+	case "edit_project":
+		var formModel tea.Model
+		formModel, cmd = m.projectForm.Update(msg)
+		m.projectForm = formModel.(ui.ProjectForm)
+
+		if m.projectForm.Done() {
+			name, client, billingStr, costStr, deadline := m.projectForm.GetValues()
+
+			updated := m.editingProject
+			updated.Name = name
+			updated.Client = client
+			updated.ClientID = m.projectForm.ClientID()
+			updated.Currency = m.formCurrency()
+			updated.Deadline = deadline
+			setPrice(&updated, billingStr, costStr)
+
+			cmd = m.execute(&history.UpdateProject{Project: m.editingProject, Updated: updated}, "Project saved")
+
+			m.refreshData()
+			m.activeView = "projects"
+		}
+	case "edit_task":
+		var formModel tea.Model
+		formModel, cmd = m.taskForm.Update(msg)
+		m.taskForm = formModel.(ui.TaskForm)
+
+		if m.taskForm.Done() {
+			title, description, deadline := m.taskForm.GetValues()
+
+			updated := m.editingTask
+			updated.Title = title
+			updated.Description = description
+			updated.Deadline = deadline
+
+			cmd = m.execute(&history.UpdateTask{Task: m.editingTask, Updated: updated}, "Task saved")
+
+			m.refreshData()
+			m.activeView = "tasks"
+		}
 	}
 
 	return m, cmd
 }
 
+// execute runs cmd through the undo history and reports the outcome in the status bar
+func (m *model) execute(cmd history.Command, success string) tea.Cmd {
+	if err := m.history.Execute(m.storage, cmd); err != nil {
+		return m.status.Error(fmt.Sprintf("Could not %s: %v", cmd.Description(), err))
+	}
+	return m.status.Success(success)
+}
+
+// setPrice stores the billing mode and amount entered in the project form.
+// The amount is the cost of a fixed price project and the rate otherwise
+func setPrice(p *models.Project, billingStr, amountStr string) {
+	// Both values have already been validated by the form
+	billing, _ := ui.ParseBilling(billingStr)
+	amount, _ := ui.ParseCost(amountStr)
+
+	p.Billing = billing
+	p.Cost, p.Rate = 0, 0
+	if billing == models.BillingFixed {
+		p.Cost = models.Cents(amount)
+	} else {
+		p.Rate = models.Cents(amount)
+	}
+}
+
+// formatPrice describes what a project is paid: its cost, or its rate per hour or day
+func formatPrice(p models.Project) string {
+	switch p.Billing {
+	case models.BillingHourly:
+		return p.Rate.Format(p.Currency) + "/h"
+	case models.BillingDaily:
+		return p.Rate.Format(p.Currency) + "/day"
+	}
+	return p.Cost.Format(p.Currency)
+}
+
+// confirm opens a dialog asking question and executes cmd once the user answers yes
+func (m *model) confirm(question string, cmd history.Command, success string) {
+	m.dialog = ui.NewConfirm(question)
+	m.pending = cmd
+	m.pendingSuccess = success
+}
+
+// projectQuestion asks whether to apply action to a project, mentioning how
+// many tasks go with it
+func projectQuestion(action string, project models.Project) string {
+	switch len(project.Tasks) {
+	case 0:
+		return fmt.Sprintf("%s '%s'?", action, project.Name)
+	case 1:
+		return fmt.Sprintf("%s '%s' and 1 task?", action, project.Name)
+	}
+	return fmt.Sprintf("%s '%s' and %d tasks?", action, project.Name, len(project.Tasks))
+}
+
+// inForm reports whether a form has the keyboard, so letters typed into it
+// are not taken as shortcuts
+func (m model) inForm() bool {
+	switch m.activeView {
+	case "new_project", "new_task", "edit_project", "edit_task", "stop_timer", "new_payment",
+		"new_client", "edit_client", "new_expense", "edit_expense":
+		return true
+	case "new_invoice":
+		return m.invoiceList.step == "details"
+	}
+	return false
+}
+
 func (m *model) updateTaskTable() {
 	m.taskTable.tasks = m.storage.GetTasks()
 }
 
+// refreshData reloads projects and tasks from storage, keeping the selected
+// project and task under the cursor when they still exist
+func (m *model) refreshData() {
+	selectedProjectID := -1
+	if m.projectList.selected < len(m.projectList.projects) {
+		selectedProjectID = m.projectList.projects[m.projectList.selected].ID
+	}
+	var selectedTaskKey [2]int
+	if tasks := m.focusedTasks(); m.taskTable.cursor < len(tasks) {
+		t := tasks[m.taskTable.cursor]
+		selectedTaskKey = [2]int{t.ProjectID, t.ID}
+	}
+
+	m.loadProjects()
+	m.updateTaskTable()
+
+	for i, p := range m.projectList.projects {
+		if p.ID == selectedProjectID {
+			m.projectList.selected = i
+		}
+	}
+	if m.projectList.selected >= len(m.projectList.projects) {
+		m.projectList.selected = len(m.projectList.projects) - 1
+	}
+	if m.projectList.selected < 0 {
+		m.projectList.selected = 0
+	}
+
+	tasks := m.focusedTasks()
+	for i, t := range tasks {
+		if [2]int{t.ProjectID, t.ID} == selectedTaskKey {
+			m.taskTable.cursor = i
+		}
+	}
+	if m.taskTable.cursor >= len(tasks) {
+		m.taskTable.cursor = len(tasks) - 1
+	}
+	if m.taskTable.cursor < 0 {
+		m.taskTable.cursor = 0
+	}
+
+	if m.activeView == "income" {
+		m.incomeChart.UpdateData(m.chartData())
+	}
+	if m.activeView == "trash" {
+		m.updateTrashList()
+	}
+	if m.activeView == "archive" {
+		m.updateArchiveList()
+	}
+	if m.activeView == "invoices" {
+		m.updateInvoiceList()
+	}
+	if m.activeView == "clients" {
+		m.updateClientList()
+	}
+	if m.activeView == "expenses" {
+		m.updateExpenseList()
+	}
+	m.reportReadError()
+}
+
+// reportReadError shows in the status bar why the backend could not load
+// data, rather than leaving the views silently empty
+func (m *model) reportReadError() {
+	if reader, ok := m.storage.(storage.ReadErrorer); ok {
+		if err := reader.ReadError(); err != nil {
+			m.status.Error(fmt.Sprintf("Could not load data: %v", err))
+		}
+	}
+}
+
+// loadProjects fills the projects grid from storage, including archived
+// projects when they are toggled on, and the ledger of their payments
+func (m *model) loadProjects() {
+	m.projectList.projects = m.storage.GetProjects()
+	if m.projectList.showArchived {
+		m.projectList.projects = append(m.projectList.projects, m.storage.GetArchivedProjects()...)
+	}
+	m.ledger = models.NewLedger(m.storage.GetInvoices(), m.storage.GetPayments())
+}
+
+// allProjects returns every project outside the trash, archived ones included
+func (m model) allProjects() []models.Project {
+	return append(m.storage.GetProjects(), m.storage.GetArchivedProjects()...)
+}
+
+// focusedTasks returns the tasks in the focused kanban column
+func (m model) focusedTasks() []models.Task {
+	switch m.taskTable.focused {
+	case "waiting":
+		return filterTasks(m.taskTable.tasks, models.TaskStatusWaiting)
+	case "in_progress":
+		return filterTasks(m.taskTable.tasks, models.TaskStatusInProgress)
+	case "done":
+		return filterTasks(m.taskTable.tasks, models.TaskStatusDone)
+	}
+	return nil
+}
+
+// View renders the active view between the running timer, if any, and the
+// confirmation dialog and status bar
 func (m model) View() string {
+	s := m.renderTimerHeader() + strings.TrimRight(m.renderView(), "\n") + "\n\n"
+	if m.dialog.Open() {
+		s += m.dialog.View() + "\n\n"
+	}
+	return s + m.status.View() + "\n"
+}
+
+func (m model) renderView() string {
 	switch m.activeView {
 	case "projects":
 		return m.renderProjects()
 	case "tasks":
 		return m.renderTasks()
-	case "new_project":
+	case "new_project", "edit_project":
 		return m.projectForm.View()
-	case "new_task":
+	case "new_task", "edit_task":
 		return m.taskForm.View()
 	case "income":
-		return m.incomeChart.View()
+		s := m.incomeChart.View()
+		if m.showTaxes {
+			s += "\n" + m.renderTaxPanel()
+		}
+		if warning := m.rateWarning(); warning != "" {
+			return s + "\n" + warning
+		}
+		return s
+	case "trash":
+		return m.renderTrash()
+	case "archive":
+		return m.renderArchive()
+	case "messages":
+		return m.renderMessageLog()
+	case "stop_timer":
+		return m.timerPrompt.View()
+	case "invoices":
+		return m.renderInvoices()
+	case "new_invoice":
+		return m.renderNewInvoice()
+	case "new_payment":
+		return m.paymentForm.View()
+	case "clients":
+		return m.renderClients()
+	case "new_client", "edit_client":
+		return m.clientForm.View()
+	case "expenses":
+		return m.renderExpenses()
+	case "new_expense", "edit_expense":
+		return m.expenseForm.View()
 	default:
 		return "Unknown view"
 	}
@@ -376,7 +839,8 @@ func (m model) View() string {
 
 func (m model) renderProjects() string {
 	var s string
-	s += "Projects (TAB: switch view, N: new project, T: new task, S: toggle status, D: delete project, ↑/↓: select, Q: quit)\n\n"
+	s += "Projects (TAB: switch view, N: new project, T: new task, E: edit project, S: toggle status, D: delete project, " +
+		"A: archive, H: show/hide archived, Shift+A: archive list, P: record payment, Shift+C: clients, Shift+I: invoices, Shift+E: expenses, B: trash, Shift+L: messages, U/Ctrl+R: undo/redo, ↑/↓: select, Q: quit)\n\n"
 
 	// Define styles for project card
 	cardStyle := lipgloss.NewStyle().
@@ -397,6 +861,11 @@ func (m model) renderProjects() string {
 			p := m.projectList.projects[i+j]
 			style := cardStyle.Copy()
 			
+			// Dim archived projects
+			if p.ArchivedAt != nil {
+				style = style.BorderForeground(lipgloss.Color("241")).Foreground(lipgloss.Color("245"))
+			}
+
 			// Highlight selected project
 			if i+j == m.projectList.selected {
 				style = style.BorderForeground(lipgloss.Color("205"))
@@ -412,11 +881,22 @@ func (m model) renderProjects() string {
 
 			// Form project card content
 			card := fmt.Sprintf(
-				"Project: %s\nClient: %s\nCost: $%.2f\nDeadline: %s\nStatus: %s\nTasks: %d",
-				p.Name, p.Client, p.Cost, p.Deadline,
+				"Project: %s\nClient: %s\nCost: %s\nDeadline: %s\nStatus: %s\nTasks: %d",
+				p.Name, p.Client, formatPrice(p), p.Deadline,
 				statusStyle.Render(p.Status),
 				len(p.Tasks),
 			)
+			if tracked := p.TrackedTime(time.Now()); tracked > 0 {
+				card += "\nTracked: " + formatDuration(tracked)
+			}
+			if p.Billing == models.BillingHourly || p.Billing == models.BillingDaily {
+				card += "\nEarned: " + p.Income(time.Now()).Format(p.Currency)
+			}
+			state := m.ledger.ProjectState(p, time.Now().Format("2006-01-02"), time.Now())
+			card += "\nPayment: " + paymentStyle(state).Render(state)
+			if p.ArchivedAt != nil {
+				card += "\nArchived: " + p.ArchivedAt.Format("2006-01-02")
+			}
 			
 			rowCards = append(rowCards, style.Render(card))
 		}
@@ -429,7 +909,7 @@ func (m model) renderProjects() string {
 }
 
 func (m model) renderTasks() string {
-	s := "Tasks View (TAB: switch views, S: change status, D: delete task, ←/→: switch columns, ↑/↓: select, Q to quit)\n\n"
+	s := "Tasks View (TAB: switch views, E: edit task, Space: start/stop timer, S: change status, D: delete task, B: trash, Shift+L: messages, U/Ctrl+R: undo/redo, ←/→: switch columns, ↑/↓: select, Q to quit)\n\n"
 
 	// Define styles for columns and cards
 	columnStyle := lipgloss.NewStyle().
@@ -481,12 +961,18 @@ func (m model) renderTasks() string {
 			}
 		}
 
-		return style.Render(fmt.Sprintf(
+		card := fmt.Sprintf(
 			"%s\n%s | %s",
 			task.Title,
 			projectName,
 			task.Deadline,
-		))
+		)
+		if _, running := task.RunningEntry(); running {
+			card += "\n⏱ " + formatDuration(task.TrackedTime(time.Now())) + " (running)"
+		} else if tracked := task.TrackedTime(time.Now()); tracked > 0 {
+			card += "\n⏱ " + formatDuration(tracked)
+		}
+		return style.Render(card)
 	}
 
 	// Render columns
@@ -532,6 +1018,43 @@ func (m model) renderTasks() string {
 	return s + lipgloss.JoinHorizontal(lipgloss.Top, waitingColumn, inProgressColumn, doneColumn)
 }
 
+// toUIProjects converts stored projects into the UI layer representation
+func toUIProjects(projects []models.Project) []ui.Project {
+	var uiProjects []ui.Project
+	for _, p := range projects {
+		var uiTasks []ui.Task
+		for _, t := range p.Tasks {
+			uiTasks = append(uiTasks, toUITask(t))
+		}
+		uiProjects = append(uiProjects, ui.Project{
+			ID:       p.ID,
+			Name:     p.Name,
+			Client:   p.Client,
+			ClientID: p.ClientID,
+			Cost:     p.Cost.Float(),
+			Billing:  p.Billing,
+			Rate:     p.Rate.Float(),
+			Currency: p.Currency,
+			Deadline: p.Deadline,
+			Status:   p.Status,
+			Tasks:    uiTasks,
+		})
+	}
+	return uiProjects
+}
+
+// toUITask converts a stored task into the UI layer representation
+func toUITask(t models.Task) ui.Task {
+	return ui.Task{
+		ID:          t.ID,
+		ProjectID:   t.ProjectID,
+		Title:       t.Title,
+		Description: t.Description,
+		Deadline:    t.Deadline,
+		Status:      t.Status,
+	}
+}
+
 // Helper function to filter tasks by status
 func filterTasks(tasks []models.Task, status string) []models.Task {
 	var filtered []models.Task
@@ -543,8 +1066,83 @@ func filterTasks(tasks []models.Task, status string) []models.Task {
 	return filtered
 }
 
+// openRepository creates the storage backend selected on the command line
+func openRepository(backend string) (storage.Repository, error) {
+	switch backend {
+	case "json":
+		return storage.NewStorage()
+	case "sqlite":
+		return storage.NewSQLiteStorage()
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
 func main() {
-	p := tea.NewProgram(initialModel())
+	backend := flag.String("storage", "json", "storage backend: json, sqlite or memory")
+	importJSON := flag.String("import-json", "", "import projects from a data.json file into the SQLite database and exit")
+	flag.Parse()
+
+	if *importJSON != "" {
+		db, err := storage.NewSQLiteStorage()
+		if err != nil {
+			fmt.Printf("Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		count, err := db.ImportJSON(*importJSON)
+		if err != nil {
+			fmt.Printf("Error importing %s: %v\n", *importJSON, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d projects from %s\n", count, *importJSON)
+		return
+	}
+
+	repo, err := openRepository(*backend)
+	if err != nil {
+		fmt.Printf("Error initializing storage: %v\n", err)
+		os.Exit(1)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var warnings []string
+	if warner, ok := repo.(storage.Warner); ok {
+		warnings = append(warnings, warner.Warnings()...)
+	}
+
+	cfg := config.Default()
+	var rates exchange.Rates
+	if *backend == "memory" {
+		// Nothing is read from or written to the data directory, so rendered
+		// invoices and reports go to the working directory
+		cfg.InvoiceDir, cfg.ReportDir = ".", "."
+	} else if dataDir, err := storage.DataDir(); err == nil {
+		if cfg, err = config.Load(dataDir); err != nil {
+			warnings = append(warnings, fmt.Sprintf("using default settings: %v", err))
+		}
+		if rates, err = exchange.Load(dataDir); err != nil {
+			warnings = append(warnings, fmt.Sprintf("exchange rates: %v", err))
+		}
+	}
+	if cfg.TrashRetentionDays > 0 {
+		if _, err := repo.PurgeTrash(time.Now().AddDate(0, 0, -cfg.TrashRetentionDays)); err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not empty expired trash: %v", err))
+		}
+	}
+
+	// Startup problems are shown in the status bar and kept in the message log
+	m := initialModel(repo, cfg)
+	m.rates = rates
+	for _, warning := range warnings {
+		m.status.Warning(warning)
+	}
+
+	p := tea.NewProgram(m)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v", err)
 		os.Exit(1)
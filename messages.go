@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/ui"
+)
+
+// messageLogPage is the number of messages shown at once in the message log
+const messageLogPage = 20
+
+// MessageLog is the state of the message log view. offset counts how many
+// messages the view is scrolled back from the newest one
+type MessageLog struct {
+	offset     int
+	returnView string
+}
+
+// updateMessageLog handles keys in the message log view
+func (m model) updateMessageLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxOffset := len(m.status.Messages()) - messageLogPage
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+
+	switch msg.String() {
+	case "esc", "L":
+		m.activeView = m.messageLog.returnView
+		m.refreshData()
+	case "up":
+		m.messageLog.offset++
+	case "down":
+		m.messageLog.offset--
+	case "pgup":
+		m.messageLog.offset += messageLogPage
+	case "pgdown":
+		m.messageLog.offset -= messageLogPage
+	case "home":
+		m.messageLog.offset = maxOffset
+	case "end":
+		m.messageLog.offset = 0
+	}
+
+	if m.messageLog.offset > maxOffset {
+		m.messageLog.offset = maxOffset
+	}
+	if m.messageLog.offset < 0 {
+		m.messageLog.offset = 0
+	}
+	return m, nil
+}
+
+func (m model) renderMessageLog() string {
+	s := "Messages (↑/↓, PgUp/PgDn: scroll, Home/End: oldest/newest, ESC: back, Q: quit)\n\n"
+
+	messages := m.status.Messages()
+	if len(messages) == 0 {
+		return s + "Nothing has happened yet in this session\n"
+	}
+
+	end := len(messages) - m.messageLog.offset
+	start := end - messageLogPage
+	if start < 0 {
+		start = 0
+	}
+
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	for _, msg := range messages[start:end] {
+		s += timeStyle.Render(msg.Time.Format("15:04:05")) + "  " + ui.RenderMessage(msg) + "\n"
+	}
+
+	s += fmt.Sprintf("\nShowing %d-%d of %d\n", start+1, end, len(messages))
+	return s
+}
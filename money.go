@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/exchange"
+	"freelancy.go/internal/models"
+)
+
+// reporting converts an amount in currency into the reporting currency at
+// the rate of date. It returns false when rates.txt has no rate for it
+func (m model) reporting(amount models.Money, currency string, date time.Time) (models.Money, bool) {
+	return m.rates.Convert(amount, currency, m.config.Currency, date)
+}
+
+// formatReporting formats an amount in the reporting currency
+func (m model) formatReporting(amount models.Money) string {
+	return amount.Format(m.config.Currency)
+}
+
+// income returns what a project has earned so far in the reporting
+// currency, converting each earning at the rate of its day
+func (m model) income(p models.Project, now time.Time) models.Money {
+	var total models.Money
+	for _, e := range p.Earnings(now) {
+		amount, _ := m.reporting(e.Amount, p.Currency, e.Date)
+		total += amount
+	}
+	return total
+}
+
+// formCurrency returns the currency entered in the project form, or the
+// reporting currency when it was left empty
+func (m model) formCurrency() string {
+	if currency := m.projectForm.Currency(); currency != "" {
+		return currency
+	}
+	return m.config.Currency
+}
+
+// missingRates returns the currencies of projects and expenses that cannot
+// be converted into the reporting currency, sorted
+func (m model) missingRates() []string {
+	var currencies []string
+	for _, p := range m.allProjects() {
+		currencies = append(currencies, p.Currency)
+	}
+	for _, e := range m.storage.GetExpenses() {
+		currencies = append(currencies, e.Currency)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	seen := make(map[string]bool)
+	var missing []string
+	for _, currency := range currencies {
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		if seen[currency] {
+			continue
+		}
+		seen[currency] = true
+		if _, ok := m.rates.Rate(currency, m.config.Currency, today); !ok {
+			missing = append(missing, currency)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// rateWarning tells which currencies are left out of converted totals, or
+// returns an empty string when every amount could be converted
+func (m model) rateWarning() string {
+	return m.missingWarning(m.missingRates())
+}
+
+// missingWarning tells that amounts in the missing currencies are left out,
+// or returns an empty string when none are missing
+func (m model) missingWarning(missing []string) string {
+	if len(missing) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Render(
+		fmt.Sprintf("Amounts in %s are left out: add exchange rates to %s in %s",
+			strings.Join(missing, ", "), m.config.Currency, exchange.FileName)) + "\n"
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+	"freelancy.go/ui"
+)
+
+// payProject opens the payment form for a project, suggesting what is
+// still owed for it
+func (m model) payProject(p models.Project) (tea.Model, tea.Cmd) {
+	owed := m.ledger.ProjectDue(p, time.Now()) - m.ledger.ProjectPaid(p.ID)
+	return m.startPayment(&history.RecordPayment{
+		Payment:  models.Payment{ProjectID: p.ID},
+		Target:   fmt.Sprintf("'%s'", p.Name),
+		Currency: p.Currency,
+	}, owed)
+}
+
+// payInvoice opens the payment form for an invoice, suggesting its unpaid
+// balance
+func (m model) payInvoice(inv models.Invoice) (tea.Model, tea.Cmd) {
+	owed := inv.Total() - m.ledger.InvoicePaid(inv.ID)
+	return m.startPayment(&history.RecordPayment{
+		Payment:  models.Payment{InvoiceID: inv.ID},
+		Target:   "invoice " + inv.Number,
+		Currency: inv.Currency,
+	}, owed)
+}
+
+// startPayment asks for the details of the payment recording will store and
+// returns to the current view afterwards
+func (m model) startPayment(recording *history.RecordPayment, owed models.Money) (tea.Model, tea.Cmd) {
+	m.recording = recording
+	m.paymentReturn = m.activeView
+	currency := recording.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	m.paymentForm = ui.NewPaymentForm(fmt.Sprintf("Payment received for %s (%s)", recording.Target, currency),
+		time.Now().Format(ui.DateLayout), owed.Float())
+	m.activeView = "new_payment"
+	return m, m.paymentForm.Init()
+}
+
+// updatePayment handles the payment form
+func (m model) updatePayment(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" {
+		m.recording = nil
+		m.activeView = m.paymentReturn
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.paymentForm, cmd = m.paymentForm.Update(msg)
+	if m.paymentForm.Done() {
+		date, amount, method := m.paymentForm.GetValues()
+		m.recording.Payment.Date, m.recording.Payment.Amount, m.recording.Payment.Method = date, models.Cents(amount), method
+		cmd = m.execute(m.recording, fmt.Sprintf("Payment of %s recorded for %s",
+			m.recording.Payment.Amount.Format(m.recording.Currency), m.recording.Target))
+		m.recording = nil
+		m.activeView = m.paymentReturn
+		m.refreshData()
+	}
+	return m, cmd
+}
+
+// chartData returns the projects shown in the income chart with their
+// earnings, the payments received for them, their expenses and forecast
+// income, followed by the general business expenses, all in the reporting
+// currency
+func (m model) chartData() ([]ui.Project, []ui.Earning) {
+	byProject := make(map[int][]models.Expense)
+	for _, e := range m.storage.GetExpenses() {
+		byProject[e.ProjectID] = append(byProject[e.ProjectID], e)
+	}
+
+	stored := m.allProjects()
+	projects := toUIProjects(stored)
+	for i, p := range stored {
+		projects[i].Earnings = m.uiEarnings(p, p.Earnings(time.Now()))
+		projects[i].Receipts = m.uiEarnings(p, m.ledger.Receipts(p.ID))
+		projects[i].Expenses = m.uiExpenses(byProject[p.ID])
+		projects[i].Forecast = m.uiEarnings(p, p.Forecast(time.Now()))
+		projects[i].Completion = p.Completion()
+		projects[i].NotForecast = p.Unforecast(time.Now())
+	}
+	return projects, m.uiExpenses(byProject[0])
+}
+
+// uiEarnings converts earnings or receipts of a project into the reporting
+// currency at the rate of their day, leaving out those without a rate
+func (m model) uiEarnings(p models.Project, earnings []models.Earning) []ui.Earning {
+	var converted []ui.Earning
+	for _, e := range earnings {
+		if amount, ok := m.reporting(e.Amount, p.Currency, e.Date); ok {
+			converted = append(converted, ui.Earning{Date: e.Date, Amount: amount.Float()})
+		}
+	}
+	return converted
+}
+
+// paymentStyle colors a payment state on project cards and in the invoice list
+func paymentStyle(state string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch state {
+	case models.PaymentPaid:
+		return style.Foreground(lipgloss.Color("42"))
+	case models.PaymentOverdue:
+		return style.Foreground(lipgloss.Color("196"))
+	case models.PaymentPartial, models.PaymentInvoiced:
+		return style.Foreground(lipgloss.Color("208"))
+	}
+	return style
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/internal/pdf"
+	"freelancy.go/ui"
+)
+
+// letterhead returns the sender details printed on PDF invoices and reports
+func (m model) letterhead() pdf.Letterhead {
+	b := m.config.Business
+	return pdf.Letterhead{
+		Name:    b.Name,
+		Address: b.Address,
+		TaxID:   b.TaxID,
+		Bank:    b.BankDetails,
+		Logo:    b.Logo,
+	}
+}
+
+// writeIncomeReport writes the periods shown in the income chart to a PDF in
+// the report directory. The report holds realised income only, never the
+// forecast
+func (m *model) writeIncomeReport() tea.Cmd {
+	now := time.Now()
+	doc, err := incomeReport(m.incomeChart.Realised(), m.incomeChart.Granularity(), m.incomeChart.Basis(),
+		m.config.Currency, m.letterhead(), now)
+	if err == nil {
+		err = os.MkdirAll(m.config.ReportDir, 0755)
+	}
+	path := filepath.Join(m.config.ReportDir, "income-report-"+now.Format("2006-01-02")+".pdf")
+	if err == nil {
+		err = doc.WriteFile(path)
+	}
+	if err != nil {
+		return m.status.Error(fmt.Sprintf("Could not write income report: %v", err))
+	}
+	return m.status.Success("Income report written to " + path)
+}
+
+// incomeReport renders the income in currency of periods of granularity g as
+// a PDF with a bar for every period, the projects that earned it and the
+// expenses and net profit of the period. basis tells whether the income was
+// earned or received
+func incomeReport(periods []ui.PeriodIncome, g ui.Granularity, basis, currency string, lh pdf.Letterhead, now time.Time) (*pdf.Document, error) {
+	money := func(amount float64) string {
+		return models.Cents(amount).Format(currency)
+	}
+
+	doc := pdf.New("Income report")
+	page := doc.AddPage()
+	y, err := doc.DrawLetterhead(page, lh)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Text(pdf.Margin, y, 20, true, "Income report")
+	y += 16
+	if len(periods) > 0 {
+		page.Text(pdf.Margin, y, 9, false, fmt.Sprintf("%s to %s, %s, %s, in %s, generated %s",
+			periods[0].Period, periods[len(periods)-1].Period, g, basis, currency, now.Format("2006-01-02")))
+	}
+	y += 36
+
+	// Averages are over the periods that have started
+	var total, expenses, highest float64
+	started := 0
+	for _, pi := range periods {
+		total += pi.Income
+		expenses += pi.Expenses
+		if pi.Income > highest {
+			highest = pi.Income
+		}
+		if !pi.Start.After(now) {
+			started++
+		}
+	}
+
+	const barLeft, barWidth = pdf.Margin + 70, 280.0
+	right := pdf.PageWidth - pdf.Margin
+	for _, pi := range periods {
+		height := 14 + 11*float64(len(pi.Projects))
+		if pi.Expenses > 0 {
+			height += 11
+		}
+		if y+height > pdf.PageHeight-pdf.Margin {
+			page = doc.AddPage()
+			y = pdf.Margin + 12
+		}
+		page.Text(pdf.Margin, y, 10, true, pi.Period)
+		if highest > 0 && pi.Income > 0 {
+			page.Rect(barLeft, y-9, barWidth*pi.Income/highest, 10, 0.6)
+		}
+		page.TextRight(right, y, 10, false, money(pi.Income))
+		y += 14
+		page.Gray(0.4)
+		for _, project := range pi.Projects {
+			page.Text(barLeft, y, 8, false, project)
+			y += 11
+		}
+		if pi.Expenses > 0 {
+			page.Text(barLeft, y, 8, false, "Expenses "+money(pi.Expenses))
+			page.TextRight(right, y, 8, false, "Net profit "+money(pi.Net()))
+			y += 11
+		}
+		page.Gray(0)
+		y += 6
+	}
+
+	// Keep the totals together below the last period
+	if y+70 > pdf.PageHeight-pdf.Margin {
+		page = doc.AddPage()
+		y = pdf.Margin + 12
+	}
+	page.Line(pdf.Margin, y, right, y, 0.5)
+	y += 18
+	page.Text(pdf.Margin, y, 11, true, "Total income")
+	page.TextRight(right, y, 11, true, money(total))
+	if expenses > 0 {
+		y += 16
+		page.Text(pdf.Margin, y, 10, false, "Total expenses")
+		page.TextRight(right, y, 10, false, money(expenses))
+		y += 16
+		page.Text(pdf.Margin, y, 11, true, "Net profit")
+		page.TextRight(right, y, 11, true, money(total-expenses))
+	}
+	if started > 0 {
+		y += 16
+		page.Text(pdf.Margin, y, 10, false, fmt.Sprintf("Average %s income", g))
+		page.TextRight(right, y, 10, false, money(total/float64(started)))
+	}
+	return doc, nil
+}
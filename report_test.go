@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"freelancy.go/internal/invoice"
+	"freelancy.go/internal/models"
+	"freelancy.go/internal/pdf"
+	"freelancy.go/ui"
+)
+
+// parsedPDF is what parsePDF reads back from a document
+type parsedPDF struct {
+	objects map[int][]byte // object bodies by number
+	text    []string       // strings shown with Tj, in order
+	// baselines are the heights of the strings above the bottom of their page
+	baselines []float64
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	streamPattern    = regexp.MustCompile(`(?s)^(<<.*?/Length (\d+) >>)\nstream\n`)
+	textPattern      = regexp.MustCompile(`([-\d.]+) Td \(((?:\\.|[^\\)])*)\) Tj`)
+	escapePattern    = regexp.MustCompile(`\\([0-7]{3}|.)`)
+)
+
+// parsePDF follows the xref table of a document to every object, checks
+// that each stream is as long as it says and collects the text shown in it
+func parsePDF(t *testing.T, data []byte) parsedPDF {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing PDF header")
+	}
+	m := startxrefPattern.FindSubmatch(data)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(data[xref:]), "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	subsection := strings.Fields(lines[1])
+	if len(subsection) != 2 {
+		t.Fatalf("bad xref subsection %q", lines[1])
+	}
+	first, _ := strconv.Atoi(subsection[0])
+	count, _ := strconv.Atoi(subsection[1])
+	if first != 0 || !strings.HasSuffix(lines[2], " f ") {
+		t.Fatalf("xref table does not start with the free object: %q", lines[2])
+	}
+	if !bytes.Contains(data[xref:], []byte("/Size "+strconv.Itoa(count)+" ")) {
+		t.Fatalf("trailer size does not match the %d xref entries", count)
+	}
+
+	doc := parsedPDF{objects: make(map[int][]byte)}
+	for n := 1; n < count; n++ {
+		offset, err := strconv.Atoi(lines[2+n][:10])
+		if err != nil || !strings.HasSuffix(lines[2+n], " n ") {
+			t.Fatalf("bad xref entry %q", lines[2+n])
+		}
+		header := []byte(strconv.Itoa(n) + " 0 obj\n")
+		if !bytes.HasPrefix(data[offset:], header) {
+			t.Fatalf("xref offset of object %d points at %q", n, data[offset:offset+10])
+		}
+		body := data[offset+len(header):]
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if end < 0 {
+			t.Fatalf("object %d is not closed", n)
+		}
+		// Only a stream that starts before the end of the object is its own
+		if s := streamPattern.FindSubmatchIndex(body[:end]); s != nil {
+			length, _ := strconv.Atoi(string(body[s[4]:s[5]]))
+			if s[1]+length > len(body) || !bytes.HasPrefix(body[s[1]+length:], []byte("\nendstream\nendobj\n")) {
+				t.Fatalf("stream of object %d is not %d bytes long", n, length)
+			}
+			end = s[1] + length
+			for _, tj := range textPattern.FindAllSubmatch(body[s[1]:end], -1) {
+				baseline, _ := strconv.ParseFloat(string(tj[1]), 64)
+				doc.text = append(doc.text, unescape(tj[2]))
+				doc.baselines = append(doc.baselines, baseline)
+			}
+		}
+		doc.objects[n] = body[:end]
+	}
+	return doc
+}
+
+// unescape decodes a PDF string literal in the WinAnsi encoding
+func unescape(s []byte) string {
+	raw := escapePattern.ReplaceAllFunc(s, func(esc []byte) []byte {
+		if len(esc) == 4 {
+			c, _ := strconv.ParseUint(string(esc[1:]), 8, 8)
+			return []byte{byte(c)}
+		}
+		return esc[1:]
+	})
+	decoded, _ := charmap.Windows1252.NewDecoder().Bytes(raw)
+	return string(decoded)
+}
+
+// contains reports whether any text shown in the document contains s
+func (doc parsedPDF) contains(s string) bool {
+	for _, text := range doc.text {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// image returns the first image object of the document
+func (doc parsedPDF) image(t *testing.T) []byte {
+	t.Helper()
+	for n := 1; n <= len(doc.objects); n++ {
+		if bytes.Contains(doc.objects[n], []byte("/Subtype /Image")) {
+			return doc.objects[n]
+		}
+	}
+	t.Fatal("no image in the document")
+	return nil
+}
+
+// testLetterhead writes a 40x20 PNG logo into dir and returns a letterhead
+// that uses it
+func testLetterhead(t *testing.T, dir string) pdf.Letterhead {
+	t.Helper()
+	logo := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		logo.Set(x, 10, color.RGBA{200, 0, 0, 255})
+	}
+	path := filepath.Join(dir, "logo.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, logo); err != nil {
+		t.Fatal(err)
+	}
+	return pdf.Letterhead{
+		Name:    "Jane Doe",
+		Address: "1 Main St\nSpringfield",
+		TaxID:   "DE123456",
+		Bank:    "IBAN DE00 1234 5678\nBIC ABCDEFGH",
+		Logo:    path,
+	}
+}
+
+// checkLetterhead checks that the document shows the sender details and
+// embeds the logo with all of its pixels
+func checkLetterhead(t *testing.T, doc parsedPDF) {
+	t.Helper()
+	for _, want := range []string{"Jane Doe", "1 Main St", "Springfield", "Tax ID: DE123456"} {
+		if !doc.contains(want) {
+			t.Errorf("letterhead %q missing from %q", want, doc.text)
+		}
+	}
+
+	img := doc.image(t)
+	if !bytes.Contains(img, []byte("/Width 40 /Height 20 /ColorSpace /DeviceRGB")) {
+		t.Fatalf("unexpected logo dictionary %q", img[:bytes.Index(img, []byte(">>"))])
+	}
+	s := streamPattern.FindSubmatchIndex(img)
+	r, err := zlib.NewReader(bytes.NewReader(img[s[1]:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pixels, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pixels) != 40*20*3 {
+		t.Fatalf("logo has %d bytes of pixels, want %d", len(pixels), 40*20*3)
+	}
+	if red := pixels[(10*40+5)*3:][:3]; !bytes.Equal(red, []byte{200, 0, 0}) {
+		t.Errorf("logo pixel is %v, want red", red)
+	}
+
+	var drawn bool
+	for _, obj := range doc.objects {
+		if bytes.Contains(obj, []byte("/Im1 Do")) {
+			drawn = true
+		}
+	}
+	if !drawn {
+		t.Error("logo is never drawn")
+	}
+}
+
+func TestInvoicePDF(t *testing.T) {
+	dir := t.TempDir()
+	lh := testLetterhead(t, dir)
+	var items []models.InvoiceItem
+	for i := 0; i < 40; i++ {
+		items = append(items, models.InvoiceItem{
+			Description: "Café (phase " + strconv.Itoa(i+1) + ")",
+			Quantity:    1.5,
+			Unit:        "h",
+			UnitPrice:   models.Cents(50),
+		})
+	}
+	inv := models.Invoice{Number: "INV-0007", Client: "Acme Corp", Currency: "EUR",
+		IssueDate: "2026-01-01", DueDate: "2026-01-15", TaxRate: 20, Items: items}
+
+	doc, err := invoice.PDF(inv, lh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed := parsePDF(t, buf.Bytes())
+
+	checkLetterhead(t, parsed)
+	for _, want := range []string{"INVOICE", "INV-0007", "Due date: 2026-01-15", "Acme Corp",
+		"Café (phase 1)", "Café (phase 40)", "Total due", inv.Total().Format("EUR"),
+		"IBAN DE00 1234 5678", "BIC ABCDEFGH"} {
+		if !parsed.contains(want) {
+			t.Errorf("%q missing from the invoice", want)
+		}
+	}
+	pages := 0
+	for _, obj := range parsed.objects {
+		if bytes.HasPrefix(obj, []byte("<< /Type /Page ")) {
+			pages++
+		}
+	}
+	if pages < 2 {
+		t.Errorf("40 items fit on %d page, want a page break", pages)
+	}
+	checkMargins(t, parsed)
+}
+
+// checkMargins checks that no text is drawn below the bottom margin of a page
+func checkMargins(t *testing.T, doc parsedPDF) {
+	t.Helper()
+	for i, baseline := range doc.baselines {
+		if baseline < pdf.Margin {
+			t.Errorf("%q drawn %g points from the bottom of the page", doc.text[i], baseline)
+		}
+	}
+}
+
+func TestInvoicePDFWrapsLongDescriptions(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("Landing page redesign with new copy and photos, ", 8))
+	word := strings.Repeat("x", 120)
+	huge := strings.TrimSpace(strings.Repeat("Support ticket ", 800))
+	var items []models.InvoiceItem
+	for i := 0; i < 30; i++ {
+		items = append(items, models.InvoiceItem{Description: "Item " + strconv.Itoa(i+1), Quantity: 1, UnitPrice: models.Cents(10)})
+	}
+	items = append(items,
+		models.InvoiceItem{Description: long, Quantity: 1, UnitPrice: models.Cents(900)},
+		models.InvoiceItem{Description: word, Quantity: 1, UnitPrice: models.Cents(5)},
+		models.InvoiceItem{Description: huge, Quantity: 1, UnitPrice: models.Cents(100)})
+	inv := models.Invoice{Number: "INV-0008", Client: "Acme Corp", Currency: "EUR",
+		IssueDate: "2026-01-01", DueDate: "2026-01-15", Items: items}
+
+	doc, err := invoice.PDF(inv, testLetterhead(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed := parsePDF(t, buf.Bytes())
+
+	checkMargins(t, parsed)
+	all := strings.Join(parsed.text, " ")
+	if !strings.Contains(all, long) {
+		t.Errorf("the long description is not shown in full: %q", parsed.text)
+	}
+	if strings.Contains(all, "...") {
+		t.Error("a description was truncated")
+	}
+	lines := 0
+	for _, text := range parsed.text {
+		if len(text) > 20 && strings.Contains(long, text) {
+			lines++
+		}
+		if strings.Trim(text, "x") == "" && pdf.TextWidth(text, 10, false) > 270 {
+			t.Errorf("a line %g points wide overflows the description column", pdf.TextWidth(text, 10, false))
+		}
+	}
+	if lines < 3 {
+		t.Errorf("the long description takes %d lines, want it wrapped", lines)
+	}
+	if !parsed.contains("Total due") || !parsed.contains(inv.Total().Format("EUR")) {
+		t.Error("totals missing")
+	}
+}
+
+func TestIncomeReportPDF(t *testing.T) {
+	dir := t.TempDir()
+	lh := testLetterhead(t, dir)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	periods := []ui.PeriodIncome{
+		{Period: "Jan 2026", Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+			Income: 1000, Projects: []string{"Website ($1000.00)"}},
+		{Period: "Feb 2026", Start: time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local),
+			Income: 500, Expenses: 200, Projects: []string{"Shop ($500.00, expenses $200.00)"}},
+		{Period: "Mar 2026", Start: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{Period: "Apr 2026", Start: time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	doc, err := incomeReport(periods, ui.Monthly, "earned", "USD", lh, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed := parsePDF(t, buf.Bytes())
+
+	checkLetterhead(t, parsed)
+	for _, want := range []string{"Income report", "Jan 2026 to Apr 2026, monthly, earned, in USD, generated 2026-03-15",
+		"Website ($1000.00)", "Expenses $200.00", "Net profit $300.00",
+		"Total income", "$1500.00", "Total expenses", "Net profit", "$1300.00",
+		// April has not started, so the average is over three months
+		"Average monthly income", "$500.00"} {
+		if !parsed.contains(want) {
+			t.Errorf("%q missing from the report %q", want, parsed.text)
+		}
+	}
+}
+
+func TestIncomeReportPDFBreaksPages(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	// Enough weeks with projects and expenses that the periods end low on a
+	// page and the totals have to go to the next one
+	var periods []ui.PeriodIncome
+	for week := 0; week < 45; week++ {
+		start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local).AddDate(0, 0, 7*week)
+		periods = append(periods, ui.PeriodIncome{Period: "Week of " + start.Format("Jan 2 2006"), Start: start,
+			Income: 300, Expenses: 50, Projects: []string{"Website ($300.00, expenses $50.00)"}})
+	}
+
+	doc, err := incomeReport(periods, ui.Weekly, "earned", "USD", pdf.Letterhead{Name: "Jane Doe"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	parsed := parsePDF(t, buf.Bytes())
+
+	checkMargins(t, parsed)
+	for _, want := range []string{"Week of Feb 9 2026", "Total income", "$13500.00", "Average weekly income"} {
+		if !parsed.contains(want) {
+			t.Errorf("%q missing from the report", want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+import "os"
+
+// fileLock is an advisory, cross-process lock held on a dedicated lock file
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile blocks until the exclusive lock on path is acquired
+func lockFile(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockHandle(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	unlockHandle(l.file)
+	return l.file.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package storage
+
+import "os"
+
+// Platforms without advisory locking fall back to the change check in Storage.update alone
+func lockHandle(file *os.File) error {
+	return nil
+}
+
+func unlockHandle(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,907 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+// MemoryStorage keeps clients, projects, tasks, invoices, payments and expenses in memory without persisting them
+type MemoryStorage struct {
+	Clients  []models.Client  `json:"clients"`
+	Projects []models.Project `json:"projects"`
+	Invoices []models.Invoice `json:"invoices"`
+	Payments []models.Payment `json:"payments"`
+	Expenses []models.Expense `json:"expenses"`
+	// LastProjectID is the highest project ID handed out so far, so that the
+	// IDs of purged projects are never reused
+	LastProjectID int `json:"last_project_id"`
+	// LastInvoiceID is the highest invoice ID handed out so far, so that
+	// the number of a deleted invoice, which may have been sent, is never
+	// given to another one
+	LastInvoiceID int `json:"last_invoice_id"`
+}
+
+// NewMemoryStorage creates an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		Clients:  make([]models.Client, 0),
+		Projects: make([]models.Project, 0),
+		Invoices: make([]models.Invoice, 0),
+		Payments: make([]models.Payment, 0),
+		Expenses: make([]models.Expense, 0),
+	}
+}
+
+// AddProject adds a new project to storage and returns its ID
+func (s *MemoryStorage) AddProject(project models.Project) (int, error) {
+	project.ID = s.nextProjectID()
+	if project.Status == "" {
+		project.Status = "Active"
+	}
+	if project.Billing == "" {
+		project.Billing = models.BillingFixed
+	}
+	if err := s.linkClient(&project); err != nil {
+		return 0, err
+	}
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	s.Projects = append(s.Projects, project)
+	return project.ID, nil
+}
+
+// AddTask adds a new task to the specified project and returns its ID
+func (s *MemoryStorage) AddTask(projectID int, task models.Task) (int, error) {
+	p := s.project(projectID)
+	if p == nil {
+		return 0, fmt.Errorf("project not found")
+	}
+	task.ID = nextTaskID(p)
+	task.ProjectID = projectID
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	p.Tasks = append(p.Tasks, task)
+	return task.ID, nil
+}
+
+// nextProjectID hands out a project ID that no project, not even a purged
+// one, has had before
+func (s *MemoryStorage) nextProjectID() int {
+	for _, p := range s.Projects {
+		s.LastProjectID = max(s.LastProjectID, p.ID)
+	}
+	s.LastProjectID++
+	return s.LastProjectID
+}
+
+// nextInvoiceID hands out an invoice ID that no invoice, not even a
+// deleted one, has had before
+func (s *MemoryStorage) nextInvoiceID() int {
+	for _, inv := range s.Invoices {
+		s.LastInvoiceID = max(s.LastInvoiceID, inv.ID)
+	}
+	s.LastInvoiceID++
+	return s.LastInvoiceID
+}
+
+// nextTaskID hands out a task ID that no task of the project, not even a
+// purged one, has had before
+func nextTaskID(p *models.Project) int {
+	for _, t := range p.Tasks {
+		p.LastTaskID = max(p.LastTaskID, t.ID)
+	}
+	p.LastTaskID++
+	return p.LastTaskID
+}
+
+// nextEntryID hands out a time entry ID that no entry of the task, not even
+// a deleted one, has had before
+func nextEntryID(t *models.Task) int {
+	for _, e := range t.TimeEntries {
+		t.LastEntryID = max(t.LastEntryID, e.ID)
+	}
+	t.LastEntryID++
+	return t.LastEntryID
+}
+
+// InsertProject puts back a project exactly as given, keeping its ID and tasks
+func (s *MemoryStorage) InsertProject(project models.Project) error {
+	i := 0
+	for ; i < len(s.Projects); i++ {
+		if s.Projects[i].ID == project.ID {
+			return fmt.Errorf("project %d already exists", project.ID)
+		}
+		if s.Projects[i].ID > project.ID {
+			break
+		}
+	}
+	if project.Tasks == nil {
+		project.Tasks = make([]models.Task, 0)
+	}
+	s.Projects = append(s.Projects[:i:i], append([]models.Project{project}, s.Projects[i:]...)...)
+	return nil
+}
+
+// InsertTask puts back a task exactly as given, keeping its ID
+func (s *MemoryStorage) InsertTask(task models.Task) error {
+	for i, p := range s.Projects {
+		if p.ID != task.ProjectID {
+			continue
+		}
+		j := 0
+		for ; j < len(p.Tasks); j++ {
+			if p.Tasks[j].ID == task.ID {
+				return fmt.Errorf("task %d already exists", task.ID)
+			}
+			if p.Tasks[j].ID > task.ID {
+				break
+			}
+		}
+		s.Projects[i].Tasks = append(p.Tasks[:j:j], append([]models.Task{task}, p.Tasks[j:]...)...)
+		return nil
+	}
+	return fmt.Errorf("project not found")
+}
+
+// GetProjects returns all projects outside the archive and the trash sorted
+// by status (active first)
+func (s *MemoryStorage) GetProjects() []models.Project {
+	projects := make([]models.Project, 0, len(s.Projects))
+	for _, p := range s.Projects {
+		if p.DeletedAt != nil || p.ArchivedAt != nil {
+			continue
+		}
+		p.Tasks = liveTasks(p.Tasks)
+		projects = append(projects, p)
+	}
+	return sortProjects(projects)
+}
+
+// sortProjects orders projects by status, keeping active projects first
+func sortProjects(projects []models.Project) []models.Project {
+	var activeProjects []models.Project
+	var completedProjects []models.Project
+
+	for _, project := range projects {
+		if project.Status == "Completed" {
+			completedProjects = append(completedProjects, project)
+		} else {
+			activeProjects = append(activeProjects, project)
+		}
+	}
+
+	sortedProjects := make([]models.Project, 0, len(projects))
+	sortedProjects = append(sortedProjects, activeProjects...)
+	sortedProjects = append(sortedProjects, completedProjects...)
+
+	return sortedProjects
+}
+
+// liveTasks returns the tasks that are not in the trash
+func liveTasks(tasks []models.Task) []models.Task {
+	live := make([]models.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if t.DeletedAt == nil {
+			live = append(live, t)
+		}
+	}
+	return live
+}
+
+// GetArchivedProjects returns the archived projects outside the trash sorted
+// by status (active first)
+func (s *MemoryStorage) GetArchivedProjects() []models.Project {
+	var projects []models.Project
+	for _, p := range s.Projects {
+		if p.DeletedAt != nil || p.ArchivedAt == nil {
+			continue
+		}
+		p.Tasks = liveTasks(p.Tasks)
+		projects = append(projects, p)
+	}
+	return sortProjects(projects)
+}
+
+// GetTasks returns all tasks outside the trash from projects that are not archived
+func (s *MemoryStorage) GetTasks() []models.Task {
+	var allTasks []models.Task
+	for _, p := range s.Projects {
+		if p.DeletedAt == nil && p.ArchivedAt == nil {
+			allTasks = append(allTasks, liveTasks(p.Tasks)...)
+		}
+	}
+	return allTasks
+}
+
+// UpdateProjectStatus changes the status of a project
+func (s *MemoryStorage) UpdateProjectStatus(projectID int, status string) error {
+	p := s.project(projectID)
+	if p == nil {
+		return fmt.Errorf("project not found")
+	}
+	p.Status = status
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateProject saves the editable fields of a project: name, client, billing,
+// cost or rate, currency and deadline
+func (s *MemoryStorage) UpdateProject(project models.Project) error {
+	p := s.project(project.ID)
+	if p == nil {
+		return fmt.Errorf("project not found")
+	}
+	if err := s.linkClient(&project); err != nil {
+		return err
+	}
+	p.Name = project.Name
+	p.Client = project.Client
+	p.ClientID = project.ClientID
+	p.Cost = project.Cost
+	p.Billing = project.Billing
+	p.Rate = project.Rate
+	p.Currency = project.Currency
+	p.Deadline = project.Deadline
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetProjectArchived moves a project into or out of the archive
+func (s *MemoryStorage) SetProjectArchived(projectID int, archived bool) error {
+	p := s.project(projectID)
+	if p == nil {
+		return fmt.Errorf("project not found")
+	}
+	if !archived {
+		p.ArchivedAt = nil
+	} else if p.ArchivedAt == nil {
+		now := time.Now()
+		p.ArchivedAt = &now
+	}
+	return nil
+}
+
+// DeleteProject moves a project and all its tasks to the trash
+func (s *MemoryStorage) DeleteProject(projectID int) error {
+	p := s.project(projectID)
+	if p == nil {
+		return fmt.Errorf("project not found")
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	return nil
+}
+
+// UpdateTaskStatus changes the status of a task and updates completion date
+func (s *MemoryStorage) UpdateTaskStatus(projectID, taskID int, status string, completedDate string) error {
+	t := s.task(projectID, taskID)
+	if t == nil {
+		return fmt.Errorf("task not found")
+	}
+	t.Status = status
+	t.UpdatedAt = time.Now()
+	if status == models.TaskStatusDone {
+		t.CompletedDate = completedDate
+	} else {
+		t.CompletedDate = ""
+	}
+	return nil
+}
+
+// UpdateTask saves the editable fields of a task: title, description and deadline
+func (s *MemoryStorage) UpdateTask(task models.Task) error {
+	t := s.task(task.ProjectID, task.ID)
+	if t == nil {
+		return fmt.Errorf("task not found")
+	}
+	t.Title = task.Title
+	t.Description = task.Description
+	t.Deadline = task.Deadline
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// AddTimeEntry records time worked on a task and returns the entry ID. An
+// entry without an end starts the timer of the task
+func (s *MemoryStorage) AddTimeEntry(projectID, taskID int, entry models.TimeEntry) (int, error) {
+	t := s.task(projectID, taskID)
+	if t == nil {
+		return 0, fmt.Errorf("task not found")
+	}
+	if _, running := t.RunningEntry(); running && entry.Running() {
+		return 0, fmt.Errorf("a timer is already running on this task")
+	}
+	entry.ID = nextEntryID(t)
+	// Copy the entries so snapshots taken from earlier reads stay unchanged
+	t.TimeEntries = append(append([]models.TimeEntry(nil), t.TimeEntries...), entry)
+	return entry.ID, nil
+}
+
+// UpdateTimeEntry saves the start, end and note of a time entry, for example
+// to stop its timer. The invoice the entry was billed on is left alone, it
+// only changes with AddInvoice and DeleteInvoice
+func (s *MemoryStorage) UpdateTimeEntry(projectID, taskID int, entry models.TimeEntry) error {
+	t := s.task(projectID, taskID)
+	if t == nil {
+		return fmt.Errorf("task not found")
+	}
+	for i, e := range t.TimeEntries {
+		if e.ID == entry.ID {
+			entries := append([]models.TimeEntry(nil), t.TimeEntries...)
+			entry.InvoiceID = e.InvoiceID
+			entries[i] = entry
+			t.TimeEntries = entries
+			return nil
+		}
+	}
+	return fmt.Errorf("time entry not found")
+}
+
+// DeleteTimeEntry permanently removes a time entry from a task. Entries
+// that have been invoiced cannot be deleted
+func (s *MemoryStorage) DeleteTimeEntry(projectID, taskID, entryID int) error {
+	t := s.task(projectID, taskID)
+	if t == nil {
+		return fmt.Errorf("task not found")
+	}
+	for i, e := range t.TimeEntries {
+		if e.ID == entryID {
+			if e.InvoiceID != 0 {
+				return fmt.Errorf("the time entry has been invoiced")
+			}
+			entries := append([]models.TimeEntry(nil), t.TimeEntries[:i]...)
+			t.TimeEntries = append(entries, t.TimeEntries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("time entry not found")
+}
+
+// GetClients returns all clients sorted by name
+func (s *MemoryStorage) GetClients() []models.Client {
+	clients := append([]models.Client(nil), s.Clients...)
+	sortClients(clients)
+	return clients
+}
+
+// sortClients orders clients by name, ignoring case
+func sortClients(clients []models.Client) {
+	sort.SliceStable(clients, func(i, j int) bool {
+		return strings.ToLower(clients[i].Name) < strings.ToLower(clients[j].Name)
+	})
+}
+
+// AddClient stores a new client and returns its ID
+func (s *MemoryStorage) AddClient(client models.Client) (int, error) {
+	if err := checkClientName(client, s.Clients); err != nil {
+		return 0, err
+	}
+	client.ID = 1
+	for _, c := range s.Clients {
+		if c.ID >= client.ID {
+			client.ID = c.ID + 1
+		}
+	}
+	s.Clients = append(s.Clients, client)
+	return client.ID, nil
+}
+
+// InsertClient puts back a client exactly as given, keeping its ID
+func (s *MemoryStorage) InsertClient(client models.Client) error {
+	if s.client(client.ID) != nil {
+		return fmt.Errorf("client %d already exists", client.ID)
+	}
+	if err := checkClientName(client, s.Clients); err != nil {
+		return err
+	}
+	s.Clients = append(s.Clients, client)
+	return nil
+}
+
+// UpdateClient saves the details of a client. Its projects, including those
+// in the archive and the trash, take on the new name
+func (s *MemoryStorage) UpdateClient(client models.Client) error {
+	c := s.client(client.ID)
+	if c == nil {
+		return fmt.Errorf("client not found")
+	}
+	if err := checkClientName(client, s.Clients); err != nil {
+		return err
+	}
+	*c = client
+	for i := range s.Projects {
+		if s.Projects[i].ClientID == client.ID {
+			s.Projects[i].Client = client.Name
+		}
+	}
+	return nil
+}
+
+// DeleteClient removes a client that no project refers to, including
+// projects in the archive and the trash
+func (s *MemoryStorage) DeleteClient(clientID int) error {
+	if s.client(clientID) == nil {
+		return fmt.Errorf("client not found")
+	}
+	for _, p := range s.Projects {
+		if p.ClientID == clientID {
+			return fmt.Errorf("the client still has projects")
+		}
+	}
+	for i, c := range s.Clients {
+		if c.ID == clientID {
+			s.Clients = append(s.Clients[:i:i], s.Clients[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// linkClient points a project at its client. A project that names a client
+// without its ID is linked to the client of that name, if there is one
+func (s *MemoryStorage) linkClient(project *models.Project) error {
+	if project.ClientID == 0 {
+		if c := findClient(s.Clients, project.Client); c != nil {
+			project.ClientID = c.ID
+			project.Client = c.Name
+		}
+		return nil
+	}
+	c := s.client(project.ClientID)
+	if c == nil {
+		return fmt.Errorf("client not found")
+	}
+	project.Client = c.Name
+	return nil
+}
+
+// client returns the client with the given ID
+func (s *MemoryStorage) client(clientID int) *models.Client {
+	for i := range s.Clients {
+		if s.Clients[i].ID == clientID {
+			return &s.Clients[i]
+		}
+	}
+	return nil
+}
+
+// findClient returns the client called name, comparing names the way
+// clientKey does
+func findClient(clients []models.Client, name string) *models.Client {
+	key := clientKey(name)
+	if key == "" {
+		return nil
+	}
+	for i := range clients {
+		if clientKey(clients[i].Name) == key {
+			return &clients[i]
+		}
+	}
+	return nil
+}
+
+// checkClientName rejects clients without a name and names that another of
+// clients already goes by
+func checkClientName(client models.Client, clients []models.Client) error {
+	if clientKey(client.Name) == "" {
+		return fmt.Errorf("client name is required")
+	}
+	if c := findClient(clients, client.Name); c != nil && c.ID != client.ID {
+		return fmt.Errorf("a client named '%s' already exists", c.Name)
+	}
+	return nil
+}
+
+// GetInvoices returns all invoices in the order they were issued
+func (s *MemoryStorage) GetInvoices() []models.Invoice {
+	return append([]models.Invoice(nil), s.Invoices...)
+}
+
+// AddInvoice stores an invoice under the next invoice number and marks the
+// work billed by its items as invoiced. It returns the invoice ID
+func (s *MemoryStorage) AddInvoice(invoice models.Invoice) (int, error) {
+	billed, err := s.billedWork(invoice.Items)
+	if err != nil {
+		return 0, err
+	}
+	for _, invoiceID := range billed {
+		if *invoiceID != 0 {
+			return 0, fmt.Errorf("some of the work has already been invoiced")
+		}
+	}
+
+	invoice.ID = s.nextInvoiceID()
+	invoice.Number = invoiceNumber(invoice.ID)
+	invoice.CreatedAt = time.Now()
+	for _, invoiceID := range billed {
+		*invoiceID = invoice.ID
+	}
+
+	s.Invoices = append(s.Invoices, invoice)
+	return invoice.ID, nil
+}
+
+// DeleteInvoice removes an invoice and marks the work it billed as unbilled again
+func (s *MemoryStorage) DeleteInvoice(invoiceID int) error {
+	for _, pay := range s.Payments {
+		if pay.InvoiceID == invoiceID {
+			return fmt.Errorf("the invoice has payments recorded against it")
+		}
+	}
+	for i, inv := range s.Invoices {
+		if inv.ID != invoiceID {
+			continue
+		}
+		for j := range s.Projects {
+			p := &s.Projects[j]
+			if p.InvoiceID == invoiceID {
+				p.InvoiceID = 0
+			}
+			for k := range p.Tasks {
+				t := &p.Tasks[k]
+				entries := append([]models.TimeEntry(nil), t.TimeEntries...)
+				for l := range entries {
+					if entries[l].InvoiceID == invoiceID {
+						entries[l].InvoiceID = 0
+					}
+				}
+				t.TimeEntries = entries
+			}
+		}
+		s.Invoices = append(s.Invoices[:i], s.Invoices[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("invoice not found")
+}
+
+// GetPayments returns all payments in the order they were recorded
+func (s *MemoryStorage) GetPayments() []models.Payment {
+	return append([]models.Payment(nil), s.Payments...)
+}
+
+// AddPayment records a payment against an invoice or a project and returns its ID
+func (s *MemoryStorage) AddPayment(payment models.Payment) (int, error) {
+	if err := s.checkPaymentTarget(payment); err != nil {
+		return 0, err
+	}
+	payment.ID = 1
+	if len(s.Payments) > 0 {
+		payment.ID = s.Payments[len(s.Payments)-1].ID + 1
+	}
+	s.Payments = append(s.Payments, payment)
+	return payment.ID, nil
+}
+
+// DeletePayment removes a payment
+func (s *MemoryStorage) DeletePayment(paymentID int) error {
+	for i, pay := range s.Payments {
+		if pay.ID == paymentID {
+			s.Payments = append(s.Payments[:i], s.Payments[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("payment not found")
+}
+
+// GetExpenses returns all expenses sorted by date, oldest first
+func (s *MemoryStorage) GetExpenses() []models.Expense {
+	expenses := append([]models.Expense(nil), s.Expenses...)
+	sortExpenses(expenses)
+	return expenses
+}
+
+// sortExpenses orders expenses by date, then in the order they were recorded
+func sortExpenses(expenses []models.Expense) {
+	sort.SliceStable(expenses, func(i, j int) bool {
+		if expenses[i].Date != expenses[j].Date {
+			return expenses[i].Date < expenses[j].Date
+		}
+		return expenses[i].ID < expenses[j].ID
+	})
+}
+
+// AddExpense records an expense of a project or of the business and returns its ID
+func (s *MemoryStorage) AddExpense(expense models.Expense) (int, error) {
+	if err := s.checkExpenseProject(expense); err != nil {
+		return 0, err
+	}
+	expense.ID = 1
+	for _, e := range s.Expenses {
+		if e.ID >= expense.ID {
+			expense.ID = e.ID + 1
+		}
+	}
+	s.Expenses = append(s.Expenses, expense)
+	return expense.ID, nil
+}
+
+// InsertExpense puts back an expense exactly as given, keeping its ID
+func (s *MemoryStorage) InsertExpense(expense models.Expense) error {
+	if s.expense(expense.ID) != nil {
+		return fmt.Errorf("expense %d already exists", expense.ID)
+	}
+	if err := s.checkExpenseProject(expense); err != nil {
+		return err
+	}
+	s.Expenses = append(s.Expenses, expense)
+	return nil
+}
+
+// UpdateExpense saves the details of an expense
+func (s *MemoryStorage) UpdateExpense(expense models.Expense) error {
+	e := s.expense(expense.ID)
+	if e == nil {
+		return fmt.Errorf("expense not found")
+	}
+	if err := s.checkExpenseProject(expense); err != nil {
+		return err
+	}
+	*e = expense
+	return nil
+}
+
+// DeleteExpense removes an expense
+func (s *MemoryStorage) DeleteExpense(expenseID int) error {
+	for i, e := range s.Expenses {
+		if e.ID == expenseID {
+			s.Expenses = append(s.Expenses[:i:i], s.Expenses[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("expense not found")
+}
+
+// expense returns the expense with the given ID
+func (s *MemoryStorage) expense(expenseID int) *models.Expense {
+	for i := range s.Expenses {
+		if s.Expenses[i].ID == expenseID {
+			return &s.Expenses[i]
+		}
+	}
+	return nil
+}
+
+// checkExpenseProject makes sure an expense is for the business or a live project
+func (s *MemoryStorage) checkExpenseProject(expense models.Expense) error {
+	if expense.ProjectID != 0 && s.project(expense.ProjectID) == nil {
+		return fmt.Errorf("project not found")
+	}
+	return nil
+}
+
+// checkPaymentTarget makes sure a payment is for an existing invoice or live project
+func (s *MemoryStorage) checkPaymentTarget(payment models.Payment) error {
+	if payment.InvoiceID != 0 {
+		for _, inv := range s.Invoices {
+			if inv.ID == payment.InvoiceID {
+				return nil
+			}
+		}
+		return fmt.Errorf("invoice not found")
+	}
+	if s.project(payment.ProjectID) == nil {
+		return fmt.Errorf("project not found")
+	}
+	return nil
+}
+
+// billedWork returns the invoice ID fields of the projects and time entries
+// billed by items. Only live, finished work can be billed
+func (s *MemoryStorage) billedWork(items []models.InvoiceItem) ([]*int, error) {
+	var billed []*int
+	copied := make(map[*models.Task]bool)
+	for _, item := range items {
+		p := s.project(item.ProjectID)
+		if p == nil {
+			return nil, fmt.Errorf("project not found")
+		}
+		if len(item.Entries) == 0 {
+			billed = append(billed, &p.InvoiceID)
+			continue
+		}
+		for _, ref := range item.Entries {
+			t := s.task(item.ProjectID, ref.TaskID)
+			if t == nil {
+				return nil, fmt.Errorf("task not found")
+			}
+			// Copy the entries so snapshots taken from earlier reads stay unchanged
+			if !copied[t] {
+				t.TimeEntries = append([]models.TimeEntry(nil), t.TimeEntries...)
+				copied[t] = true
+			}
+			found := false
+			for i := range t.TimeEntries {
+				if e := &t.TimeEntries[i]; e.ID == ref.EntryID && !e.Running() {
+					billed = append(billed, &e.InvoiceID)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("time entry not found")
+			}
+		}
+	}
+	return billed, nil
+}
+
+// DeleteTask moves a task to the trash
+func (s *MemoryStorage) DeleteTask(projectID, taskID int) error {
+	t := s.task(projectID, taskID)
+	if t == nil {
+		return fmt.Errorf("task not found")
+	}
+	now := time.Now()
+	t.DeletedAt = &now
+	return nil
+}
+
+// GetTrash returns the projects in the trash together with all their tasks,
+// and the trashed tasks whose project is not in the trash
+func (s *MemoryStorage) GetTrash() ([]models.Project, []models.Task) {
+	var projects []models.Project
+	var tasks []models.Task
+	for _, p := range s.Projects {
+		if p.DeletedAt != nil {
+			projects = append(projects, p)
+			continue
+		}
+		for _, t := range p.Tasks {
+			if t.DeletedAt != nil {
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	return projects, tasks
+}
+
+// RestoreProject takes a project out of the trash
+func (s *MemoryStorage) RestoreProject(projectID int) error {
+	for i, p := range s.Projects {
+		if p.ID == projectID && p.DeletedAt != nil {
+			s.Projects[i].DeletedAt = nil
+			return nil
+		}
+	}
+	return fmt.Errorf("project not found in trash")
+}
+
+// RestoreTask takes a task out of the trash
+func (s *MemoryStorage) RestoreTask(projectID, taskID int) error {
+	p := s.project(projectID)
+	if p == nil {
+		return fmt.Errorf("project not found")
+	}
+	for j, t := range p.Tasks {
+		if t.ID == taskID && t.DeletedAt != nil {
+			p.Tasks[j].DeletedAt = nil
+			return nil
+		}
+	}
+	return fmt.Errorf("task not found in trash")
+}
+
+// PurgeProject permanently removes a project and all its tasks. Projects
+// that invoices, payments or expenses refer to cannot be purged
+func (s *MemoryStorage) PurgeProject(projectID int) error {
+	for i, p := range s.Projects {
+		if p.ID == projectID {
+			if err := s.checkPurgeProject(projectID); err != nil {
+				return err
+			}
+			s.Projects = append(s.Projects[:i:i], s.Projects[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("project not found")
+}
+
+// PurgeTask permanently removes a task from its project. Tasks with
+// invoiced time cannot be purged
+func (s *MemoryStorage) PurgeTask(projectID, taskID int) error {
+	for i, p := range s.Projects {
+		if p.ID == projectID {
+			for j, t := range p.Tasks {
+				if t.ID == taskID {
+					if err := checkPurgeTask(t); err != nil {
+						return err
+					}
+					s.Projects[i].Tasks = append(p.Tasks[:j:j], p.Tasks[j+1:]...)
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("task not found")
+}
+
+// PurgeTrash permanently removes projects and tasks that were moved to the
+// trash before the given time and returns how many entries were removed.
+// Projects and tasks that cannot be purged stay in the trash
+func (s *MemoryStorage) PurgeTrash(before time.Time) (int, error) {
+	purged := 0
+	projects := make([]models.Project, 0, len(s.Projects))
+	for _, p := range s.Projects {
+		if p.DeletedAt != nil && p.DeletedAt.Before(before) && s.checkPurgeProject(p.ID) == nil {
+			purged++
+			continue
+		}
+		tasks := make([]models.Task, 0, len(p.Tasks))
+		for _, t := range p.Tasks {
+			if t.DeletedAt != nil && t.DeletedAt.Before(before) && checkPurgeTask(t) == nil {
+				purged++
+				continue
+			}
+			tasks = append(tasks, t)
+		}
+		p.Tasks = tasks
+		projects = append(projects, p)
+	}
+	s.Projects = projects
+	return purged, nil
+}
+
+// checkPurgeProject refuses to purge a project that invoices, payments or
+// expenses still refer to
+func (s *MemoryStorage) checkPurgeProject(projectID int) error {
+	for _, inv := range s.Invoices {
+		for _, item := range inv.Items {
+			if item.ProjectID == projectID {
+				return fmt.Errorf("the project has been invoiced")
+			}
+		}
+	}
+	for _, pay := range s.Payments {
+		if pay.ProjectID == projectID {
+			return fmt.Errorf("the project has payments recorded against it")
+		}
+	}
+	for _, e := range s.Expenses {
+		if e.ProjectID == projectID {
+			return fmt.Errorf("the project has expenses recorded against it")
+		}
+	}
+	return nil
+}
+
+// checkPurgeTask refuses to purge a task with invoiced time
+func checkPurgeTask(task models.Task) error {
+	for _, e := range task.TimeEntries {
+		if e.InvoiceID != 0 {
+			return fmt.Errorf("the task has invoiced time")
+		}
+	}
+	return nil
+}
+
+// project returns the project with the given ID unless it is in the trash
+func (s *MemoryStorage) project(projectID int) *models.Project {
+	for i := range s.Projects {
+		if s.Projects[i].ID == projectID && s.Projects[i].DeletedAt == nil {
+			return &s.Projects[i]
+		}
+	}
+	return nil
+}
+
+// task returns the given task unless it or its project is in the trash
+func (s *MemoryStorage) task(projectID, taskID int) *models.Task {
+	p := s.project(projectID)
+	if p == nil {
+		return nil
+	}
+	for j := range p.Tasks {
+		if p.Tasks[j].ID == taskID && p.Tasks[j].DeletedAt == nil {
+			return &p.Tasks[j]
+		}
+	}
+	return nil
+}
+
+// invoiceNumber formats the sequential number printed on an invoice
+func invoiceNumber(invoiceID int) string {
+	return fmt.Sprintf("INV-%04d", invoiceID)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+// Repository describes the operations the application needs from a storage backend
+type Repository interface {
+	AddProject(project models.Project) (int, error)
+	AddTask(projectID int, task models.Task) (int, error)
+	InsertProject(project models.Project) error
+	InsertTask(task models.Task) error
+	GetProjects() []models.Project
+	GetTasks() []models.Task
+	UpdateProjectStatus(projectID int, status string) error
+	UpdateTaskStatus(projectID, taskID int, status string, completedDate string) error
+	UpdateProject(project models.Project) error
+	UpdateTask(task models.Task) error
+	DeleteProject(projectID int) error
+	DeleteTask(projectID, taskID int) error
+
+	// Archive
+	GetArchivedProjects() []models.Project
+	SetProjectArchived(projectID int, archived bool) error
+
+	// Time tracking
+	AddTimeEntry(projectID, taskID int, entry models.TimeEntry) (int, error)
+	UpdateTimeEntry(projectID, taskID int, entry models.TimeEntry) error
+	DeleteTimeEntry(projectID, taskID, entryID int) error
+
+	// Clients
+	GetClients() []models.Client
+	AddClient(client models.Client) (int, error)
+	InsertClient(client models.Client) error
+	UpdateClient(client models.Client) error
+	DeleteClient(clientID int) error
+
+	// Invoices
+	GetInvoices() []models.Invoice
+	AddInvoice(invoice models.Invoice) (int, error)
+	DeleteInvoice(invoiceID int) error
+
+	// Payments
+	GetPayments() []models.Payment
+	AddPayment(payment models.Payment) (int, error)
+	DeletePayment(paymentID int) error
+
+	// Expenses
+	GetExpenses() []models.Expense
+	AddExpense(expense models.Expense) (int, error)
+	InsertExpense(expense models.Expense) error
+	UpdateExpense(expense models.Expense) error
+	DeleteExpense(expenseID int) error
+
+	// Trash
+	GetTrash() ([]models.Project, []models.Task)
+	RestoreProject(projectID int) error
+	RestoreTask(projectID, taskID int) error
+	PurgeProject(projectID int) error
+	PurgeTask(projectID, taskID int) error
+	PurgeTrash(before time.Time) (int, error)
+}
+
+// Warner is implemented by backends that can report problems they recovered
+// from while loading, such as falling back to a backup file
+type Warner interface {
+	Warnings() []string
+}
+
+// ReadErrorer is implemented by backends whose reads can fail. A Get method
+// that fails returns no data, and ReadError then tells what went wrong
+type ReadErrorer interface {
+	ReadError() error
+}
+
+var (
+	_ ReadErrorer = (*SQLiteStorage)(nil)
+
+	_ Repository = (*Storage)(nil)
+	_ Repository = (*MemoryStorage)(nil)
+	_ Repository = (*SQLiteStorage)(nil)
+)
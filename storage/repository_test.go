@@ -0,0 +1,528 @@
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+// backend opens a fresh repository of one kind for a test. reopen, when set,
+// opens the same data again the way a restarted application would
+type backend struct {
+	name   string
+	open   func(t *testing.T) Repository
+	reopen func(t *testing.T, repo Repository) Repository
+}
+
+var backends = []backend{
+	{
+		name: "memory",
+		open: func(t *testing.T) Repository { return NewMemoryStorage() },
+	},
+	{
+		name: "json",
+		open: func(t *testing.T) Repository {
+			s, err := NewFileStorage(filepath.Join(t.TempDir(), "data.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		reopen: func(t *testing.T, repo Repository) Repository {
+			s, err := NewFileStorage(repo.(*Storage).dataFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	},
+	{
+		name: "sqlite",
+		open: func(t *testing.T) Repository {
+			return openSQLite(t, filepath.Join(t.TempDir(), "data.db"))
+		},
+		reopen: func(t *testing.T, repo Repository) Repository {
+			var file string
+			if err := repo.(*SQLiteStorage).db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+				t.Fatal(err)
+			}
+			return openSQLite(t, file)
+		},
+	},
+}
+
+// openSQLite opens a database file that is closed when the test ends
+func openSQLite(t *testing.T, file string) *SQLiteStorage {
+	t.Helper()
+	s, err := NewSQLiteFileStorage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// forEachBackend runs test against a fresh repository of every kind
+func forEachBackend(t *testing.T, test func(t *testing.T, repo Repository)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b.open(t))
+		})
+	}
+}
+
+// mustAdd returns a function that fails the test when an Add or Purge call
+// returns an error, and passes on the ID or count it returned otherwise
+func mustAdd(t *testing.T) func(n int, err error) int {
+	return func(n int, err error) int {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+}
+
+// checkNoReadError fails the test when a read of repo failed
+func checkNoReadError(t *testing.T, repo Repository) {
+	t.Helper()
+	if r, ok := repo.(ReadErrorer); ok {
+		if err := r.ReadError(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// at returns a pointer to t, for the end of a finished time entry
+func at(t time.Time) *time.Time {
+	return &t
+}
+
+// findProject returns the project with the given ID from projects
+func findProject(t *testing.T, projects []models.Project, id int) models.Project {
+	t.Helper()
+	for _, p := range projects {
+		if p.ID == id {
+			return p
+		}
+	}
+	t.Fatalf("project %d not found", id)
+	return models.Project{}
+}
+
+func TestRepositoryProjectsAndTasks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		before := time.Now().Add(-time.Second)
+		website := must(repo.AddProject(models.Project{Name: "Website", Cost: models.Cents(1500), Deadline: "2026-06-30"}))
+		shop := must(repo.AddProject(models.Project{Name: "Shop"}))
+		if website == shop {
+			t.Fatalf("both projects got ID %d", website)
+		}
+		design := must(repo.AddTask(website, models.Task{Title: "Design", Deadline: "2026-05-01", Status: models.TaskStatusWaiting}))
+		build := must(repo.AddTask(website, models.Task{Title: "Build", Status: models.TaskStatusWaiting}))
+		if _, err := repo.AddTask(999, models.Task{Title: "Orphan"}); err == nil {
+			t.Error("added a task to a missing project")
+		}
+
+		p := findProject(t, repo.GetProjects(), website)
+		if p.Status != "Active" || p.Billing != models.BillingFixed {
+			t.Errorf("new project has status %q and billing %q", p.Status, p.Billing)
+		}
+		if p.CreatedAt.Before(before) || !p.UpdatedAt.Equal(p.CreatedAt) {
+			t.Errorf("new project created at %v, updated at %v", p.CreatedAt, p.UpdatedAt)
+		}
+		if len(p.Tasks) != 2 || p.Tasks[0].Title != "Design" || p.Tasks[0].ProjectID != website {
+			t.Fatalf("project has tasks %+v", p.Tasks)
+		}
+
+		if err := repo.UpdateTaskStatus(website, design, models.TaskStatusDone, "2026-04-20"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateProjectStatus(shop, "Completed"); err != nil {
+			t.Fatal(err)
+		}
+		p.Name, p.Cost = "New website", models.Cents(2000)
+		if err := repo.UpdateProject(p); err != nil {
+			t.Fatal(err)
+		}
+		projects := repo.GetProjects()
+		if projects[len(projects)-1].ID != shop {
+			t.Error("completed projects are not listed last")
+		}
+		p = findProject(t, projects, website)
+		if p.Name != "New website" || p.Cost != models.Cents(2000) {
+			t.Errorf("project not updated: %q %v", p.Name, p.Cost)
+		}
+		if p.Tasks[0].Status != models.TaskStatusDone || p.Tasks[0].CompletedDate != "2026-04-20" {
+			t.Errorf("task status %q completed %q", p.Tasks[0].Status, p.Tasks[0].CompletedDate)
+		}
+		if err := repo.UpdateTaskStatus(website, design, models.TaskStatusWaiting, "2026-04-20"); err != nil {
+			t.Fatal(err)
+		}
+		if task := repo.GetTasks()[0]; task.CompletedDate != "" {
+			t.Errorf("reopened task keeps completed date %q", task.CompletedDate)
+		}
+
+		// Deleted tasks and projects go to the trash and can come back
+		if err := repo.DeleteTask(website, build); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(repo.GetTasks()); n != 1 {
+			t.Errorf("%d tasks outside the trash, want 1", n)
+		}
+		if _, tasks := repo.GetTrash(); len(tasks) != 1 || tasks[0].ID != build {
+			t.Errorf("trash holds tasks %+v", tasks)
+		}
+		if err := repo.RestoreTask(website, build); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteProject(shop); err != nil {
+			t.Fatal(err)
+		}
+		if projects, _ := repo.GetTrash(); len(projects) != 1 || projects[0].ID != shop {
+			t.Errorf("trash holds projects %+v", projects)
+		}
+		if len(repo.GetProjects()) != 1 {
+			t.Error("a trashed project is still listed")
+		}
+		if err := repo.RestoreProject(shop); err != nil {
+			t.Fatal(err)
+		}
+
+		// Archived projects and their tasks are kept apart
+		if err := repo.SetProjectArchived(website, true); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.GetProjects()) != 1 || len(repo.GetTasks()) != 0 {
+			t.Error("an archived project is still listed")
+		}
+		if archived := repo.GetArchivedProjects(); len(archived) != 1 || archived[0].ArchivedAt == nil || len(archived[0].Tasks) != 2 {
+			t.Errorf("archive holds %+v", archived)
+		}
+		if err := repo.SetProjectArchived(website, false); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.GetProjects()) != 2 || len(repo.GetArchivedProjects()) != 0 {
+			t.Error("an unarchived project is not listed")
+		}
+
+		for name, err := range map[string]error{
+			"UpdateProjectStatus": repo.UpdateProjectStatus(999, "Completed"),
+			"UpdateTaskStatus":    repo.UpdateTaskStatus(website, 999, models.TaskStatusDone, ""),
+			"DeleteProject":       repo.DeleteProject(999),
+			"DeleteTask":          repo.DeleteTask(999, design),
+		} {
+			if err == nil {
+				t.Errorf("%s of a missing item succeeded", name)
+			}
+		}
+		checkNoReadError(t, repo)
+	})
+}
+
+func TestRepositoryIDsAreNeverReused(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		first := must(repo.AddProject(models.Project{Name: "First"}))
+		second := must(repo.AddProject(models.Project{Name: "Second"}))
+		task := must(repo.AddTask(first, models.Task{Title: "Task"}))
+
+		entry := must(repo.AddTimeEntry(first, task, models.TimeEntry{Start: time.Now()}))
+		if err := repo.DeleteTimeEntry(first, task, entry); err != nil {
+			t.Fatal(err)
+		}
+		if next := must(repo.AddTimeEntry(first, task, models.TimeEntry{Start: time.Now()})); next == entry {
+			t.Errorf("deleted time entry ID %d was reused", entry)
+		}
+
+		if err := repo.PurgeTask(first, task); err != nil {
+			t.Fatal(err)
+		}
+		if next := must(repo.AddTask(first, models.Task{Title: "Next"})); next == task {
+			t.Errorf("purged task ID %d was reused", task)
+		}
+		if err := repo.PurgeProject(second); err != nil {
+			t.Fatal(err)
+		}
+		if next := must(repo.AddProject(models.Project{Name: "Next"})); next == second {
+			t.Errorf("purged project ID %d was reused", second)
+		}
+	})
+}
+
+func TestRepositoryInvoiceNumbersAreNeverReused(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		first := must(repo.AddProject(models.Project{Name: "First"}))
+		second := must(repo.AddProject(models.Project{Name: "Second"}))
+		bill := func(project int) models.Invoice {
+			t.Helper()
+			id := must(repo.AddInvoice(models.Invoice{Client: "Acme", Currency: "USD",
+				Items: []models.InvoiceItem{{Description: "Work", Quantity: 1, UnitPrice: models.Cents(100), ProjectID: project}}}))
+			for _, inv := range repo.GetInvoices() {
+				if inv.ID == id {
+					return inv
+				}
+			}
+			t.Fatalf("invoice %d not found", id)
+			return models.Invoice{}
+		}
+
+		deleted := bill(first)
+		if err := repo.DeleteInvoice(deleted.ID); err != nil {
+			t.Fatal(err)
+		}
+		// A failed add does not use up a number either
+		if _, err := repo.AddInvoice(models.Invoice{Items: []models.InvoiceItem{{ProjectID: 999}}}); err == nil {
+			t.Fatal("invoiced a missing project")
+		}
+		next := bill(second)
+		if next.ID == deleted.ID || next.Number == deleted.Number {
+			t.Errorf("deleted invoice %s (ID %d) was reused", deleted.Number, deleted.ID)
+		}
+		if next.Number != "INV-0002" {
+			t.Errorf("next invoice is %s, want INV-0002", next.Number)
+		}
+	})
+}
+
+func TestRepositoryTimeEntriesAndInvoices(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		project := must(repo.AddProject(models.Project{Name: "Support", Billing: models.BillingHourly, Rate: models.Cents(80)}))
+		task := must(repo.AddTask(project, models.Task{Title: "Fixes"}))
+		start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+		entry := must(repo.AddTimeEntry(project, task, models.TimeEntry{Start: start, End: at(start.Add(2 * time.Hour)), Note: "Login bug"}))
+		running := must(repo.AddTimeEntry(project, task, models.TimeEntry{Start: start.Add(3 * time.Hour)}))
+
+		entries := repo.GetTasks()[0].TimeEntries
+		if len(entries) != 2 || entries[0].ID != entry || !entries[1].Running() {
+			t.Fatalf("task has entries %+v", entries)
+		}
+		if !entries[0].Start.Equal(start) || entries[0].Duration(time.Now()) != 2*time.Hour || entries[0].Note != "Login bug" {
+			t.Errorf("entry read back as %+v", entries[0])
+		}
+
+		item := models.InvoiceItem{Description: "Fixes", Quantity: 2, Unit: "h", UnitPrice: models.Cents(80), ProjectID: project,
+			Entries: []models.TimeEntryRef{{TaskID: task, EntryID: entry}}}
+		invoice := must(repo.AddInvoice(models.Invoice{Client: "Acme", Currency: "USD", IssueDate: "2026-03-31",
+			DueDate: "2026-04-14", Items: []models.InvoiceItem{item}}))
+		if _, err := repo.AddInvoice(models.Invoice{Items: []models.InvoiceItem{item}}); err == nil {
+			t.Error("billed the same time entry twice")
+		}
+		timer := item
+		timer.Entries = []models.TimeEntryRef{{TaskID: task, EntryID: running}}
+		if _, err := repo.AddInvoice(models.Invoice{Items: []models.InvoiceItem{timer}}); err == nil {
+			t.Error("billed a running timer")
+		}
+		invoices := repo.GetInvoices()
+		if len(invoices) != 1 || invoices[0].Number == "" || len(invoices[0].Items) != 1 || invoices[0].Items[0].Entries[0].EntryID != entry {
+			t.Fatalf("invoices read back as %+v", invoices)
+		}
+
+		// Editing a billed entry keeps it on its invoice
+		edited := repo.GetTasks()[0].TimeEntries[0]
+		edited.Note, edited.InvoiceID = "Login and logout bugs", 0
+		if err := repo.UpdateTimeEntry(project, task, edited); err != nil {
+			t.Fatal(err)
+		}
+		if e := repo.GetTasks()[0].TimeEntries[0]; e.InvoiceID != invoice || e.Note != "Login and logout bugs" {
+			t.Errorf("edited entry has invoice %d and note %q", e.InvoiceID, e.Note)
+		}
+
+		// Paid invoices and invoiced work cannot go away
+		payment := must(repo.AddPayment(models.Payment{InvoiceID: invoice, Amount: models.Cents(160), Date: "2026-04-10"}))
+		if err := repo.DeleteInvoice(invoice); err == nil {
+			t.Error("deleted an invoice with payments")
+		}
+		if err := repo.PurgeTask(project, task); err == nil {
+			t.Error("purged a task with invoiced time")
+		}
+		if err := repo.DeleteTimeEntry(project, task, entry); err == nil {
+			t.Error("deleted an invoiced time entry")
+		}
+		if err := repo.PurgeProject(project); err == nil {
+			t.Error("purged an invoiced project")
+		}
+		if err := repo.DeletePayment(payment); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteInvoice(invoice); err != nil {
+			t.Fatal(err)
+		}
+		if e := repo.GetTasks()[0].TimeEntries[0]; e.InvoiceID != 0 {
+			t.Errorf("entry still billed on deleted invoice %d", e.InvoiceID)
+		}
+
+		if err := repo.DeleteTimeEntry(project, task, running); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(repo.GetTasks()[0].TimeEntries); n != 1 {
+			t.Errorf("%d entries left, want 1", n)
+		}
+		checkNoReadError(t, repo)
+	})
+}
+
+func TestRepositoryClientsPaymentsAndExpenses(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		acme := must(repo.AddClient(models.Client{Name: "Acme", Currency: "EUR", Rate: models.Cents(90)}))
+		project := must(repo.AddProject(models.Project{Name: "Website", Client: "Acme"}))
+		if p := repo.GetProjects()[0]; p.ClientID != acme {
+			t.Errorf("project named after a client is linked to %d, want %d", p.ClientID, acme)
+		}
+		if _, err := repo.AddProject(models.Project{Name: "Lost", ClientID: 999}); err == nil {
+			t.Error("added a project for a missing client")
+		}
+
+		client := repo.GetClients()[0]
+		client.Name = "Acme Corp"
+		if err := repo.UpdateClient(client); err != nil {
+			t.Fatal(err)
+		}
+		if p := repo.GetProjects()[0]; p.Client != "Acme Corp" {
+			t.Errorf("renamed client shows as %q on its project", p.Client)
+		}
+		if err := repo.DeleteClient(acme); err == nil {
+			t.Error("deleted a client that still has projects")
+		}
+
+		if _, err := repo.AddPayment(models.Payment{ProjectID: 999, Amount: 1}); err == nil {
+			t.Error("recorded a payment for a missing project")
+		}
+		payment := must(repo.AddPayment(models.Payment{ProjectID: project, Amount: models.Cents(500), Date: "2026-02-01", Method: "bank transfer"}))
+		if payments := repo.GetPayments(); len(payments) != 1 || payments[0].ID != payment || payments[0].Method != "bank transfer" {
+			t.Errorf("payments read back as %+v", payments)
+		}
+
+		expense := must(repo.AddExpense(models.Expense{ProjectID: project, Date: "2026-02-03", Amount: models.Cents(40),
+			Currency: "EUR", Category: "Software", Note: "Fonts"}))
+		general := must(repo.AddExpense(models.Expense{Date: "2026-02-05", Amount: models.Cents(12), Category: "Office"}))
+		if expense == general {
+			t.Fatalf("both expenses got ID %d", expense)
+		}
+		e := repo.GetExpenses()[0]
+		e.Amount = models.Cents(45)
+		if err := repo.UpdateExpense(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteExpense(general); err != nil {
+			t.Fatal(err)
+		}
+		if expenses := repo.GetExpenses(); len(expenses) != 1 || expenses[0].Amount != models.Cents(45) || expenses[0].Note != "Fonts" {
+			t.Errorf("expenses read back as %+v", expenses)
+		}
+		if err := repo.PurgeProject(project); err == nil {
+			t.Error("purged a project with payments and expenses")
+		}
+
+		if err := repo.DeletePayment(payment); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteExpense(expense); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.PurgeProject(project); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.DeleteClient(acme); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.GetClients()) != 0 {
+			t.Error("deleted client is still listed")
+		}
+		checkNoReadError(t, repo)
+	})
+}
+
+func TestRepositoryPurgeTrash(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repo Repository) {
+		must := mustAdd(t)
+		kept := must(repo.AddProject(models.Project{Name: "Kept"}))
+		paid := must(repo.AddProject(models.Project{Name: "Paid"}))
+		trashed := must(repo.AddProject(models.Project{Name: "Trashed"}))
+		task := must(repo.AddTask(kept, models.Task{Title: "Trashed task"}))
+		must(repo.AddPayment(models.Payment{ProjectID: paid, Amount: models.Cents(10)}))
+		for _, err := range []error{repo.DeleteProject(paid), repo.DeleteProject(trashed), repo.DeleteTask(kept, task)} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n := must(repo.PurgeTrash(time.Now().Add(-time.Hour))); n != 0 {
+			t.Errorf("purged %d items deleted after the cutoff", n)
+		}
+		if n := must(repo.PurgeTrash(time.Now().Add(time.Hour))); n != 2 {
+			t.Errorf("purged %d items, want 2", n)
+		}
+		projects, tasks := repo.GetTrash()
+		if len(projects) != 1 || projects[0].ID != paid || len(tasks) != 0 {
+			t.Errorf("trash holds %+v and %+v, want only the paid project", projects, tasks)
+		}
+		if len(repo.GetProjects()) != 1 {
+			t.Error("purging the trash touched a live project")
+		}
+		checkNoReadError(t, repo)
+	})
+}
+
+// snapshot is everything a repository returns, as JSON
+func snapshot(t *testing.T, repo Repository) string {
+	t.Helper()
+	trashProjects, trashTasks := repo.GetTrash()
+	data, err := json.MarshalIndent(map[string]any{
+		"projects": repo.GetProjects(),
+		"archived": repo.GetArchivedProjects(),
+		"trash":    []any{trashProjects, trashTasks},
+		"clients":  repo.GetClients(),
+		"invoices": repo.GetInvoices(),
+		"payments": repo.GetPayments(),
+		"expenses": repo.GetExpenses(),
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNoReadError(t, repo)
+	return string(data)
+}
+
+func TestRepositoryPersists(t *testing.T) {
+	for _, b := range backends {
+		if b.reopen == nil {
+			continue
+		}
+		t.Run(b.name, func(t *testing.T) {
+			repo := b.open(t)
+			must := mustAdd(t)
+			client := must(repo.AddClient(models.Client{Name: "Acme", Email: "billing@acme.test"}))
+			project := must(repo.AddProject(models.Project{Name: "Support", ClientID: client, Billing: models.BillingDaily,
+				Rate: models.Cents(600), Currency: "EUR", Deadline: "2026-09-30"}))
+			task := must(repo.AddTask(project, models.Task{Title: "Fixes", Description: "Bugs", Status: models.TaskStatusWaiting}))
+			start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+			entry := must(repo.AddTimeEntry(project, task, models.TimeEntry{Start: start, End: at(start.Add(time.Hour))}))
+			invoice := must(repo.AddInvoice(models.Invoice{Client: "Acme", Currency: "EUR", IssueDate: "2026-03-31", TaxRate: 19,
+				Items: []models.InvoiceItem{{Description: "Support", Quantity: 1, Unit: "days", UnitPrice: models.Cents(600),
+					ProjectID: project, Entries: []models.TimeEntryRef{{TaskID: task, EntryID: entry}}}}}))
+			must(repo.AddPayment(models.Payment{InvoiceID: invoice, Amount: models.Cents(714), Date: "2026-04-02"}))
+			must(repo.AddExpense(models.Expense{Date: "2026-03-05", Amount: models.Cents(30), Category: "Hosting"}))
+			archived := must(repo.AddProject(models.Project{Name: "Old"}))
+			if err := repo.SetProjectArchived(archived, true); err != nil {
+				t.Fatal(err)
+			}
+			trashed := must(repo.AddProject(models.Project{Name: "Scrapped"}))
+			if err := repo.DeleteProject(trashed); err != nil {
+				t.Fatal(err)
+			}
+
+			want := snapshot(t, repo)
+			if got := snapshot(t, b.reopen(t, repo)); got != want {
+				t.Errorf("reopened data differs\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
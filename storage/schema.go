@@ -0,0 +1,505 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"freelancy.go/internal/models"
+)
+
+// SchemaVersion is the data file format written by this version of freelancy.
+// Files without a schema_version field are treated as version 1
+const SchemaVersion = 13
+
+// ErrNewerSchema is returned for data files written by a newer freelancy
+var ErrNewerSchema = errors.New("data file was written by a newer version of freelancy")
+
+// migration upgrades a decoded data file by exactly one schema version
+type migration func(doc map[string]any) error
+
+// migrations[i] upgrades a data file from version i+1 to version i+2
+var migrations = []migration{
+	migrateV1ToV2,
+	migrateV2ToV3,
+	migrateV3ToV4,
+	migrateV4ToV5,
+	migrateV5ToV6,
+	migrateV6ToV7,
+	migrateV7ToV8,
+	migrateV8ToV9,
+	migrateV9ToV10,
+	migrateV10ToV11,
+	migrateV11ToV12,
+	migrateV12ToV13,
+}
+
+// deadlineLayouts lists the date formats found in data files written before
+// deadlines were normalized, including the RFC 3339 timestamps produced by
+// the old time.Time based project model
+var deadlineLayouts = []string{
+	"2006-01-02",
+	time.RFC3339Nano,
+	"2006/01/02",
+	"2006/1/2",
+	"2006-1-2",
+	"02.01.2006",
+	"2.1.2006",
+}
+
+// upgradeData runs every migration needed to bring data up to SchemaVersion.
+// backup is called with the original version and contents before each step.
+// It reports whether any migration was applied
+func upgradeData(data []byte, backup func(version int, data []byte) error) ([]byte, bool, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, err
+	}
+
+	version := 1
+	if v, ok := doc["schema_version"].(float64); ok {
+		version = int(v)
+	}
+	if version > SchemaVersion {
+		return nil, false, fmt.Errorf("%w (schema version %d, supported up to %d)", ErrNewerSchema, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return data, false, nil
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if backup != nil {
+			if err := backup(version, data); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := migrations[version-1](doc); err != nil {
+			return nil, false, fmt.Errorf("upgrading data file to schema version %d: %w", version+1, err)
+		}
+		doc["schema_version"] = version + 1
+
+		upgraded, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, false, err
+		}
+		data = upgraded
+	}
+
+	return data, true, nil
+}
+
+// migrateV1ToV2 normalizes project and task deadlines to YYYY-MM-DD, fills in
+// missing project statuses and backfills project creation times from their
+// oldest task
+func migrateV1ToV2(doc map[string]any) error {
+	field := takeField(doc, "projects")
+	projects, ok := field.([]any)
+	if !ok && field != nil {
+		return fmt.Errorf("unexpected projects %v", field)
+	}
+	doc["projects"] = projects
+	if projects == nil {
+		doc["projects"] = []any{}
+	}
+
+	now := time.Now()
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		canonicalizeKeys(project, "id", "name", "client", "cost", "deadline", "status", "tasks", "created_at")
+		project["deadline"] = normalizeDeadline(project["deadline"])
+		if status, _ := project["status"].(string); status == "" {
+			project["status"] = "Active"
+		}
+
+		tasks, _ := project["tasks"].([]any)
+		if tasks == nil {
+			project["tasks"] = []any{}
+		}
+
+		var oldest time.Time
+		for _, t := range tasks {
+			task, ok := t.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected task entry %v", t)
+			}
+			canonicalizeKeys(task, "id", "project_id", "title", "description", "deadline", "status",
+				"completed_date", "created_at", "updated_at")
+			task["deadline"] = normalizeDeadline(task["deadline"])
+
+			if s, ok := task["created_at"].(string); ok {
+				if created, err := time.Parse(time.RFC3339Nano, s); err == nil && !created.IsZero() {
+					if oldest.IsZero() || created.Before(oldest) {
+						oldest = created
+					}
+				}
+			}
+		}
+
+		if s, _ := project["created_at"].(string); s != "" {
+			if created, err := time.Parse(time.RFC3339Nano, s); err == nil && !created.IsZero() {
+				continue
+			}
+		}
+		if oldest.IsZero() {
+			oldest = now
+		}
+		project["created_at"] = oldest.Format(time.RFC3339Nano)
+	}
+
+	return nil
+}
+
+// migrateV2ToV3 adds the project modification time, starting out equal to
+// the creation time
+func migrateV2ToV3(doc map[string]any) error {
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		if _, ok := project["updated_at"]; !ok {
+			project["updated_at"] = project["created_at"]
+		}
+	}
+	return nil
+}
+
+// migrateV3ToV4 changes nothing in the data: version 4 adds optional time
+// entries to tasks. The version bump keeps older versions of freelancy, which
+// would drop the entries when saving, from opening the file
+func migrateV3ToV4(doc map[string]any) error {
+	return nil
+}
+
+// migrateV4ToV5 adds the billing mode of projects; existing projects are
+// fixed price
+func migrateV4ToV5(doc map[string]any) error {
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		if billing, _ := project["billing"].(string); billing == "" {
+			project["billing"] = "fixed"
+		}
+		if _, ok := project["rate"]; !ok {
+			project["rate"] = 0
+		}
+	}
+	return nil
+}
+
+// migrateV5ToV6 adds the list of invoices, starting out empty
+func migrateV5ToV6(doc map[string]any) error {
+	if _, ok := doc["invoices"].([]any); !ok {
+		doc["invoices"] = []any{}
+	}
+	return nil
+}
+
+// migrateV6ToV7 adds the list of payments, starting out empty
+func migrateV6ToV7(doc map[string]any) error {
+	if _, ok := doc["payments"].([]any); !ok {
+		doc["payments"] = []any{}
+	}
+	return nil
+}
+
+// migrateV7ToV8 turns the client names typed into projects into client
+// records, merging different spellings of the same client
+func migrateV7ToV8(doc map[string]any) error {
+	projects, _ := doc["projects"].([]any)
+	names := make([]string, len(projects))
+	for i, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		names[i], _ = project["client"].(string)
+	}
+
+	clients, ids := groupClients(names)
+	for i, p := range projects {
+		if ids[i] != 0 {
+			project := p.(map[string]any)
+			project["client_id"] = ids[i]
+			project["client"] = clients[ids[i]-1].Name
+		}
+	}
+	records := make([]any, len(clients))
+	for i, c := range clients {
+		records[i] = map[string]any{"id": c.ID, "name": c.Name}
+	}
+	doc["clients"] = records
+	return nil
+}
+
+// migrateV8ToV9 stores amounts as whole cents and gives projects and
+// invoices a currency: that of their client, or US dollars, which all
+// amounts were shown in until then
+func migrateV8ToV9(doc map[string]any) error {
+	clientCurrency := make(map[float64]string)
+	clients, _ := doc["clients"].([]any)
+	for _, c := range clients {
+		client, ok := c.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected client entry %v", c)
+		}
+		toCents(client, "rate")
+		id, _ := client["id"].(float64)
+		clientCurrency[id], _ = client["currency"].(string)
+	}
+
+	projectCurrency := make(map[float64]string)
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		toCents(project, "cost")
+		toCents(project, "rate")
+		clientID, _ := project["client_id"].(float64)
+		currency := clientCurrency[clientID]
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		project["currency"] = currency
+		id, _ := project["id"].(float64)
+		projectCurrency[id] = currency
+	}
+
+	invoices, _ := doc["invoices"].([]any)
+	for _, i := range invoices {
+		invoice, ok := i.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected invoice entry %v", i)
+		}
+		invoice["currency"] = models.DefaultCurrency
+		items, _ := invoice["items"].([]any)
+		for n, it := range items {
+			item, ok := it.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected invoice item %v", it)
+			}
+			toCents(item, "unit_price")
+			projectID, _ := item["project_id"].(float64)
+			if currency := projectCurrency[projectID]; n == 0 && currency != "" {
+				invoice["currency"] = currency
+			}
+		}
+	}
+
+	payments, _ := doc["payments"].([]any)
+	for _, p := range payments {
+		payment, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected payment entry %v", p)
+		}
+		toCents(payment, "amount")
+	}
+	return nil
+}
+
+// migrateV9ToV10 adds the list of expenses, starting out empty
+func migrateV9ToV10(doc map[string]any) error {
+	if _, ok := doc["expenses"].([]any); !ok {
+		doc["expenses"] = []any{}
+	}
+	return nil
+}
+
+// migrateV10ToV11 records the highest project ID and the highest task ID of
+// every project, so that the IDs of purged projects and tasks are not reused
+func migrateV10ToV11(doc map[string]any) error {
+	lastProjectID := 0.0
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		id, _ := project["id"].(float64)
+		lastProjectID = max(lastProjectID, id)
+
+		lastTaskID := 0.0
+		tasks, _ := project["tasks"].([]any)
+		for _, t := range tasks {
+			task, ok := t.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected task entry %v", t)
+			}
+			id, _ := task["id"].(float64)
+			lastTaskID = max(lastTaskID, id)
+		}
+		project["last_task_id"] = lastTaskID
+	}
+	doc["last_project_id"] = lastProjectID
+	return nil
+}
+
+// migrateV11ToV12 records the highest invoice ID, so that the numbers of
+// deleted invoices are not reused
+func migrateV11ToV12(doc map[string]any) error {
+	lastInvoiceID := 0.0
+	invoices, _ := doc["invoices"].([]any)
+	for _, i := range invoices {
+		invoice, ok := i.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected invoice entry %v", i)
+		}
+		id, _ := invoice["id"].(float64)
+		lastInvoiceID = max(lastInvoiceID, id)
+	}
+	doc["last_invoice_id"] = lastInvoiceID
+	return nil
+}
+
+// migrateV12ToV13 records the highest time entry ID of every task, so that
+// the IDs of deleted entries are not reused
+func migrateV12ToV13(doc map[string]any) error {
+	projects, _ := doc["projects"].([]any)
+	for _, p := range projects {
+		project, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unexpected project entry %v", p)
+		}
+		tasks, _ := project["tasks"].([]any)
+		for _, t := range tasks {
+			task, ok := t.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected task entry %v", t)
+			}
+			lastEntryID := 0.0
+			entries, _ := task["time_entries"].([]any)
+			for _, e := range entries {
+				entry, ok := e.(map[string]any)
+				if !ok {
+					return fmt.Errorf("unexpected time entry %v", e)
+				}
+				id, _ := entry["id"].(float64)
+				lastEntryID = max(lastEntryID, id)
+			}
+			if lastEntryID > 0 {
+				task["last_entry_id"] = lastEntryID
+			}
+		}
+	}
+	return nil
+}
+
+// toCents converts the amount in the member name from currency units to
+// whole cents
+func toCents(obj map[string]any, name string) {
+	if amount, ok := obj[name].(float64); ok {
+		obj[name] = models.Cents(amount)
+	}
+}
+
+// legalSuffixes are left out when client names are compared, so that
+// "Acme" and "ACME Inc." are the same client
+var legalSuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "llc": true, "ltd": true, "limited": true, "plc": true,
+	"corp": true, "corporation": true, "co": true, "company": true, "gmbh": true, "ag": true,
+	"sa": true, "sarl": true, "bv": true, "oy": true, "ab": true, "pty": true,
+}
+
+// clientKey reduces a client name to what matters when comparing names:
+// letters and digits in lower case, without legal suffixes
+func clientKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	for len(words) > 1 && legalSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// groupClients creates a client for every distinct name in names, as compared
+// by clientKey, and returns the clients with the client ID of each name (0
+// for empty names). A client is named after its most common spelling, or the
+// first one seen on a tie
+func groupClients(names []string) ([]models.Client, []int) {
+	var clients []models.Client
+	ids := make([]int, len(names))
+	byKey := make(map[string]int)
+	spellings := make(map[int]map[string]int)
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		key := clientKey(name)
+		if key == "" {
+			continue
+		}
+		id, ok := byKey[key]
+		if !ok {
+			id = len(clients) + 1
+			byKey[key] = id
+			clients = append(clients, models.Client{ID: id, Name: name})
+			spellings[id] = make(map[string]int)
+		}
+		ids[i] = id
+		spellings[id][name]++
+		if spellings[id][name] > spellings[id][clients[id-1].Name] {
+			clients[id-1].Name = name
+		}
+	}
+	return clients, ids
+}
+
+// normalizeDeadline converts a deadline in any known layout to YYYY-MM-DD.
+// Values that cannot be parsed are kept as they are
+func normalizeDeadline(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	for _, layout := range deadlineLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+// canonicalizeKeys renames members that match one of names case-insensitively,
+// the way encoding/json matches them, to their canonical spelling
+func canonicalizeKeys(obj map[string]any, names ...string) {
+	for _, name := range names {
+		if v := takeField(obj, name); v != nil {
+			obj[name] = v
+		}
+	}
+}
+
+// takeField removes the member matching name case-insensitively, also
+// accepting the underscore-free Go field spelling, and returns its value
+func takeField(obj map[string]any, name string) any {
+	plain := strings.ReplaceAll(name, "_", "")
+	if v, ok := obj[name]; ok {
+		delete(obj, name)
+		return v
+	}
+	for key, v := range obj {
+		if strings.EqualFold(key, name) || strings.EqualFold(key, plain) {
+			delete(obj, key)
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// upgrade runs every migration on a data file and decodes the result
+func upgrade(t *testing.T, data string) MemoryStorage {
+	t.Helper()
+	upgraded, migrated, err := upgradeData([]byte(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated {
+		t.Fatal("no migration was applied")
+	}
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(upgraded, &doc); err != nil || doc.SchemaVersion != SchemaVersion {
+		t.Fatalf("upgraded to schema version %d (%v), want %d", doc.SchemaVersion, err, SchemaVersion)
+	}
+	var s MemoryStorage
+	if err := json.Unmarshal(upgraded, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUpgradeNormalizesDeadlines(t *testing.T) {
+	tests := []struct {
+		deadline any
+		want     string
+	}{
+		{"2026-03-01", "2026-03-01"},
+		{" 2026-03-01 ", "2026-03-01"},
+		{"2026-03-01T00:00:00+03:00", "2026-03-01"},
+		{"2026-03-01T23:30:00.5Z", "2026-03-01"},
+		{"0001-01-01T00:00:00Z", ""},
+		{"2026/03/01", "2026-03-01"},
+		{"2026/3/1", "2026-03-01"},
+		{"2026-3-1", "2026-03-01"},
+		{"01.03.2026", "2026-03-01"},
+		{"1.3.2026", "2026-03-01"},
+		{"", ""},
+		{nil, ""},
+		{42, ""},
+		{"next spring", "next spring"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.deadline), func(t *testing.T) {
+			deadline, _ := json.Marshal(tt.deadline)
+			s := upgrade(t, fmt.Sprintf(`{"projects": [{"id": 1, "name": "Website", "deadline": %s,
+				"tasks": [{"id": 1, "title": "Design", "deadline": %s}]}]}`, deadline, deadline))
+			if got := s.Projects[0].Deadline; got != tt.want {
+				t.Errorf("project deadline %q, want %q", got, tt.want)
+			}
+			if got := s.Projects[0].Tasks[0].Deadline; got != tt.want {
+				t.Errorf("task deadline %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeBackfillsCreatedAt(t *testing.T) {
+	before := time.Now().Add(-time.Second)
+	s := upgrade(t, `{"projects": [
+		{"ID": 1, "Name": "Tasks", "Tasks": [
+			{"ID": 1, "Title": "Later", "CreatedAt": "2025-05-02T10:00:00Z"},
+			{"ID": 2, "Title": "Earlier", "CreatedAt": "2025-04-01T08:30:00+02:00"},
+			{"ID": 3, "Title": "Unknown", "CreatedAt": "0001-01-01T00:00:00Z"}]},
+		{"id": 2, "name": "Dated", "created_at": "2024-12-24T18:00:00Z",
+			"tasks": [{"id": 1, "title": "Older task", "created_at": "2024-01-01T00:00:00Z"}]},
+		{"id": 3, "name": "Empty"},
+		{"id": 4, "name": "Zero", "created_at": "0001-01-01T00:00:00Z"}
+	]}`)
+
+	want := []time.Time{
+		time.Date(2025, 4, 1, 6, 30, 0, 0, time.UTC),
+		time.Date(2024, 12, 24, 18, 0, 0, 0, time.UTC),
+	}
+	for i, p := range s.Projects {
+		if i < len(want) {
+			if !p.CreatedAt.Equal(want[i]) {
+				t.Errorf("project %q created at %v, want %v", p.Name, p.CreatedAt, want[i])
+			}
+		} else if p.CreatedAt.Before(before) || p.CreatedAt.After(time.Now()) {
+			t.Errorf("project %q without tasks created at %v, want the time of the upgrade", p.Name, p.CreatedAt)
+		}
+		if !p.UpdatedAt.Equal(p.CreatedAt) {
+			t.Errorf("project %q updated at %v, want its creation time %v", p.Name, p.UpdatedAt, p.CreatedAt)
+		}
+		if p.Status != "Active" || p.Billing != "fixed" {
+			t.Errorf("project %q has status %q and billing %q", p.Name, p.Status, p.Billing)
+		}
+	}
+	if s.Projects[0].Name != "Tasks" || len(s.Projects[0].Tasks) != 3 {
+		t.Errorf("Go field spellings were not read: %+v", s.Projects[0])
+	}
+}
+
+func TestOpenBacksUpBeforeUpgrading(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	original := []byte(`{"projects": [{"id": 1, "name": "Website", "deadline": "2026/3/1", "tasks": []}]}`)
+	if err := os.WriteFile(dataFile, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewFileStorage(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := s.GetProjects(); len(p) != 1 || p[0].Deadline != "2026-03-01" {
+		t.Fatalf("upgraded projects %+v", p)
+	}
+
+	// Every version is kept as it was before the step that upgraded it
+	v1, err := os.ReadFile(dataFile + ".v1.bak")
+	if err != nil || !bytes.Equal(v1, original) {
+		t.Errorf("version 1 backup is %q (%v), want the original file", v1, err)
+	}
+	for version := 2; version < SchemaVersion; version++ {
+		data, err := os.ReadFile(fmt.Sprintf("%s.v%d.bak", dataFile, version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			SchemaVersion int `json:"schema_version"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil || doc.SchemaVersion != version {
+			t.Errorf("version %d backup has schema version %d (%v)", version, doc.SchemaVersion, err)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.v%d.bak", dataFile, SchemaVersion)); !os.IsNotExist(err) {
+		t.Errorf("the current version was backed up as an old one (%v)", err)
+	}
+
+	// The upgraded file is saved, so opening it again migrates nothing
+	upgraded, err := os.ReadFile(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(dataFile + ".v1.bak")
+	if _, err := NewFileStorage(dataFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dataFile + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("an up to date file was backed up again")
+	}
+	if again, _ := os.ReadFile(dataFile); !bytes.Equal(again, upgraded) {
+		t.Error("an up to date file was rewritten")
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	newer := []byte(fmt.Sprintf(`{"schema_version": %d, "projects": []}`, SchemaVersion+1))
+	if err := os.WriteFile(dataFile, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileStorage(dataFile); !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("opening a newer file returned %v, want ErrNewerSchema", err)
+	}
+	if data, _ := os.ReadFile(dataFile); !bytes.Equal(data, newer) {
+		t.Error("the newer file was changed")
+	}
+}
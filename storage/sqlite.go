@@ -0,0 +1,1557 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"freelancy.go/internal/models"
+)
+
+// sqliteMigrations holds the schema changes applied in order; the index of
+// the last applied migration plus one is stored in PRAGMA user_version
+var sqliteMigrations = []string{
+	`CREATE TABLE projects (
+		id       INTEGER PRIMARY KEY,
+		name     TEXT NOT NULL,
+		client   TEXT NOT NULL,
+		cost     REAL NOT NULL,
+		deadline TEXT NOT NULL,
+		status   TEXT NOT NULL
+	);
+	CREATE TABLE tasks (
+		project_id     INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+		id             INTEGER NOT NULL,
+		title          TEXT NOT NULL,
+		description    TEXT NOT NULL,
+		deadline       TEXT NOT NULL,
+		status         TEXT NOT NULL,
+		completed_date TEXT NOT NULL,
+		created_at     TEXT NOT NULL,
+		updated_at     TEXT NOT NULL,
+		PRIMARY KEY (project_id, id)
+	);`,
+	`ALTER TABLE projects ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+	UPDATE projects SET created_at = COALESCE(
+		(SELECT MIN(created_at) FROM tasks WHERE tasks.project_id = projects.id),
+		strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+	);`,
+	`ALTER TABLE projects ADD COLUMN deleted_at TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tasks ADD COLUMN deleted_at TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE projects ADD COLUMN archived_at TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE projects ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
+	UPDATE projects SET updated_at = created_at;`,
+	`CREATE TABLE time_entries (
+		project_id INTEGER NOT NULL,
+		task_id    INTEGER NOT NULL,
+		id         INTEGER NOT NULL,
+		started_at TEXT NOT NULL,
+		ended_at   TEXT NOT NULL, -- empty while the timer is running
+		note       TEXT NOT NULL,
+		PRIMARY KEY (project_id, task_id, id),
+		FOREIGN KEY (project_id, task_id) REFERENCES tasks(project_id, id) ON DELETE CASCADE
+	);`,
+	`ALTER TABLE projects ADD COLUMN billing TEXT NOT NULL DEFAULT 'fixed';
+	ALTER TABLE projects ADD COLUMN rate REAL NOT NULL DEFAULT 0;`,
+	`CREATE TABLE invoices (
+		id         INTEGER PRIMARY KEY,
+		number     TEXT NOT NULL,
+		client     TEXT NOT NULL,
+		issue_date TEXT NOT NULL,
+		due_date   TEXT NOT NULL,
+		tax_rate   REAL NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE invoice_items (
+		invoice_id  INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+		position    INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		quantity    REAL NOT NULL,
+		unit        TEXT NOT NULL,
+		unit_price  REAL NOT NULL,
+		project_id  INTEGER NOT NULL,
+		entries     TEXT NOT NULL, -- JSON list of the billed time entries
+		PRIMARY KEY (invoice_id, position)
+	);
+	ALTER TABLE projects ADD COLUMN invoice_id INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE time_entries ADD COLUMN invoice_id INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE payments (
+		id         INTEGER PRIMARY KEY,
+		date       TEXT NOT NULL,
+		amount     REAL NOT NULL,
+		method     TEXT NOT NULL,
+		project_id INTEGER NOT NULL, -- 0 for payments of an invoice
+		invoice_id INTEGER NOT NULL  -- 0 for payments made directly for a project
+	);`,
+	`CREATE TABLE clients (
+		id       INTEGER PRIMARY KEY,
+		name     TEXT NOT NULL,
+		email    TEXT NOT NULL,
+		phone    TEXT NOT NULL,
+		address  TEXT NOT NULL,
+		currency TEXT NOT NULL,
+		rate     REAL NOT NULL,
+		notes    TEXT NOT NULL
+	);
+	ALTER TABLE projects ADD COLUMN client_id INTEGER NOT NULL DEFAULT 0;`,
+	// Amounts become whole cents, and projects and invoices get a currency:
+	// that of their client, or US dollars, which all amounts were shown in
+	`ALTER TABLE projects ADD COLUMN cost_cents INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE projects ADD COLUMN rate_cents INTEGER NOT NULL DEFAULT 0;
+	UPDATE projects SET cost_cents = CAST(ROUND(cost * 100) AS INTEGER), rate_cents = CAST(ROUND(rate * 100) AS INTEGER);
+	ALTER TABLE projects DROP COLUMN cost;
+	ALTER TABLE projects DROP COLUMN rate;
+	ALTER TABLE projects RENAME COLUMN cost_cents TO cost;
+	ALTER TABLE projects RENAME COLUMN rate_cents TO rate;
+	ALTER TABLE clients ADD COLUMN rate_cents INTEGER NOT NULL DEFAULT 0;
+	UPDATE clients SET rate_cents = CAST(ROUND(rate * 100) AS INTEGER);
+	ALTER TABLE clients DROP COLUMN rate;
+	ALTER TABLE clients RENAME COLUMN rate_cents TO rate;
+	ALTER TABLE invoice_items ADD COLUMN unit_price_cents INTEGER NOT NULL DEFAULT 0;
+	UPDATE invoice_items SET unit_price_cents = CAST(ROUND(unit_price * 100) AS INTEGER);
+	ALTER TABLE invoice_items DROP COLUMN unit_price;
+	ALTER TABLE invoice_items RENAME COLUMN unit_price_cents TO unit_price;
+	ALTER TABLE payments ADD COLUMN amount_cents INTEGER NOT NULL DEFAULT 0;
+	UPDATE payments SET amount_cents = CAST(ROUND(amount * 100) AS INTEGER);
+	ALTER TABLE payments DROP COLUMN amount;
+	ALTER TABLE payments RENAME COLUMN amount_cents TO amount;
+	ALTER TABLE projects ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+	UPDATE projects SET currency = COALESCE(
+		(SELECT currency FROM clients WHERE clients.id = projects.client_id AND currency != ''),
+		'USD'
+	);
+	ALTER TABLE invoices ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+	UPDATE invoices SET currency = COALESCE(
+		(SELECT projects.currency FROM invoice_items JOIN projects ON projects.id = invoice_items.project_id
+			WHERE invoice_items.invoice_id = invoices.id ORDER BY invoice_items.position LIMIT 1),
+		'USD'
+	);`,
+	`CREATE TABLE expenses (
+		id         INTEGER PRIMARY KEY,
+		date       TEXT NOT NULL,
+		amount     INTEGER NOT NULL,
+		currency   TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		note       TEXT NOT NULL,
+		receipt    TEXT NOT NULL,
+		project_id INTEGER NOT NULL -- 0 for general business expenses
+	);`,
+	// Remember the highest project and task IDs handed out, so that the IDs
+	// of purged projects and tasks are not reused
+	`CREATE TABLE id_sequences (
+		name    TEXT PRIMARY KEY,
+		last_id INTEGER NOT NULL
+	);
+	INSERT INTO id_sequences (name, last_id) SELECT 'projects', COALESCE(MAX(id), 0) FROM projects;
+	ALTER TABLE projects ADD COLUMN last_task_id INTEGER NOT NULL DEFAULT 0;
+	UPDATE projects SET last_task_id = COALESCE((SELECT MAX(id) FROM tasks WHERE tasks.project_id = projects.id), 0);`,
+	// Remember the highest invoice ID handed out, so that the numbers of
+	// deleted invoices are not reused
+	`INSERT INTO id_sequences (name, last_id) SELECT 'invoices', COALESCE(MAX(id), 0) FROM invoices;`,
+	// Remember the highest time entry ID of every task, so that the IDs of
+	// deleted entries are not reused
+	`ALTER TABLE tasks ADD COLUMN last_entry_id INTEGER NOT NULL DEFAULT 0;
+	UPDATE tasks SET last_entry_id = COALESCE((SELECT MAX(id) FROM time_entries
+		WHERE time_entries.project_id = tasks.project_id AND time_entries.task_id = tasks.id), 0);`,
+}
+
+// sqliteDataMigrations complete the migration with the same index in
+// sqliteMigrations with changes that cannot be expressed in SQL
+var sqliteDataMigrations = map[int]func(tx *sql.Tx) error{
+	9: migrateSQLiteClients,
+}
+
+// SQLiteStorage persists clients, projects, tasks, invoices, payments and expenses in an embedded SQLite database
+type SQLiteStorage struct {
+	db *sql.DB
+
+	errMu   sync.Mutex
+	readErr error // the first read error not yet returned by ReadError
+}
+
+// NewSQLiteStorage opens the default database in the freelancy data directory
+func NewSQLiteStorage() (*SQLiteStorage, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSQLiteFileStorage(filepath.Join(dataDir, "data.db"))
+}
+
+// NewSQLiteFileStorage opens the given database file and brings its schema up to date
+func NewSQLiteFileStorage(dbFile string) (*SQLiteStorage, error) {
+	dsn := "file:" + filepath.ToSlash(dbFile) +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &SQLiteStorage{db: db}
+	if err := storage.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// ReadError returns the first error a Get method ran into since the last
+// call and forgets it
+func (s *SQLiteStorage) ReadError() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	err := s.readErr
+	s.readErr = nil
+	return err
+}
+
+// readFailed keeps the error of a failed Get method for ReadError
+func (s *SQLiteStorage) readFailed(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	if s.readErr == nil {
+		s.readErr = err
+	}
+}
+
+// Close releases the database handle
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// migrate applies all schema migrations newer than the database version
+func (s *SQLiteStorage) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sqlite migration %d: %w", version+1, err)
+		}
+		if migrateData, ok := sqliteDataMigrations[version]; ok {
+			if err := migrateData(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("sqlite migration %d: %w", version+1, err)
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateSQLiteClients turns the client names typed into projects into client
+// records, merging different spellings of the same client
+func migrateSQLiteClients(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, client FROM projects ORDER BY id")
+	if err != nil {
+		return err
+	}
+	var projectIDs []int
+	var names []string
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		projectIDs = append(projectIDs, id)
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	clients, ids := groupClients(names)
+	for _, c := range clients {
+		if err := insertClient(tx, c); err != nil {
+			return err
+		}
+	}
+	for i, projectID := range projectIDs {
+		if ids[i] == 0 {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE projects SET client_id = ?, client = ? WHERE id = ?",
+			ids[i], clients[ids[i]-1].Name, projectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportJSON copies all clients, projects, tasks, invoices, payments and expenses from a data.json file into the
+// database, keeping their IDs and timestamps; the database must be empty
+func (s *SQLiteStorage) ImportJSON(dataFile string) (int, error) {
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		return 0, err
+	}
+
+	data, _, err = upgradeData(data, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var source MemoryStorage
+	if err := json.Unmarshal(data, &source); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM projects").Scan(&count); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, fmt.Errorf("database already contains %d projects", count)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, c := range source.Clients {
+		if err := insertClient(tx, c); err != nil {
+			return 0, err
+		}
+	}
+	for _, p := range source.Projects {
+		if err := insertProject(tx, p); err != nil {
+			return 0, err
+		}
+		for _, t := range p.Tasks {
+			t.ProjectID = p.ID
+			if err := insertTask(tx, t); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for _, inv := range source.Invoices {
+		if err := insertInvoice(tx, inv); err != nil {
+			return 0, err
+		}
+	}
+	for _, pay := range source.Payments {
+		if err := insertPayment(tx, pay); err != nil {
+			return 0, err
+		}
+	}
+	for _, e := range source.Expenses {
+		if err := insertExpense(tx, e); err != nil {
+			return 0, err
+		}
+	}
+	for name, last := range map[string]int{"projects": source.LastProjectID, "invoices": source.LastInvoiceID} {
+		if _, err := tx.Exec("UPDATE id_sequences SET last_id = MAX(last_id, ?) WHERE name = ?", last, name); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(source.Projects), nil
+}
+
+// AddProject adds a new project to storage and returns its ID
+func (s *SQLiteStorage) AddProject(project models.Project) (int, error) {
+	if project.Status == "" {
+		project.Status = "Active"
+	}
+	if project.Billing == "" {
+		project.Billing = models.BillingFixed
+	}
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := linkClient(tx, &project); err != nil {
+		return 0, err
+	}
+	if project.ID, err = nextSQLiteProjectID(tx); err != nil {
+		return 0, err
+	}
+	if err := insertProject(tx, project); err != nil {
+		return 0, err
+	}
+	return project.ID, tx.Commit()
+}
+
+// AddTask adds a new task to the specified project and returns its ID
+func (s *SQLiteStorage) AddTask(projectID int, task models.Task) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = ? AND deleted_at = '')", projectID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("project not found")
+	}
+
+	if task.ID, err = nextSQLiteTaskID(tx, projectID); err != nil {
+		return 0, err
+	}
+	task.ProjectID = projectID
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = time.Now()
+	if err := insertTask(tx, task); err != nil {
+		return 0, err
+	}
+	return task.ID, tx.Commit()
+}
+
+// nextSQLiteProjectID hands out a project ID that no project, not even a
+// purged one, has had before
+func nextSQLiteProjectID(tx *sql.Tx) (int, error) {
+	var id int
+	err := tx.QueryRow(`UPDATE id_sequences SET last_id = MAX(last_id, (SELECT COALESCE(MAX(id), 0) FROM projects)) + 1
+		WHERE name = 'projects' RETURNING last_id`).Scan(&id)
+	return id, err
+}
+
+// nextSQLiteInvoiceID hands out an invoice ID that no invoice, not even a
+// deleted one, has had before
+func nextSQLiteInvoiceID(tx *sql.Tx) (int, error) {
+	var id int
+	err := tx.QueryRow(`UPDATE id_sequences SET last_id = MAX(last_id, (SELECT COALESCE(MAX(id), 0) FROM invoices)) + 1
+		WHERE name = 'invoices' RETURNING last_id`).Scan(&id)
+	return id, err
+}
+
+// nextSQLiteTaskID hands out a task ID that no task of the project, not
+// even a purged one, has had before
+func nextSQLiteTaskID(tx *sql.Tx, projectID int) (int, error) {
+	var id int
+	err := tx.QueryRow(`UPDATE projects SET last_task_id = MAX(last_task_id,
+			(SELECT COALESCE(MAX(id), 0) FROM tasks WHERE project_id = projects.id)) + 1
+		WHERE id = ? RETURNING last_task_id`, projectID).Scan(&id)
+	return id, err
+}
+
+// nextSQLiteEntryID hands out a time entry ID that no entry of the task, not
+// even a deleted one, has had before
+func nextSQLiteEntryID(tx *sql.Tx, projectID, taskID int) (int, error) {
+	var id int
+	err := tx.QueryRow(`UPDATE tasks SET last_entry_id = MAX(last_entry_id,
+			(SELECT COALESCE(MAX(id), 0) FROM time_entries WHERE project_id = tasks.project_id AND task_id = tasks.id)) + 1
+		WHERE project_id = ? AND id = ? RETURNING last_entry_id`, projectID, taskID).Scan(&id)
+	return id, err
+}
+
+// InsertProject puts back a project exactly as given, keeping its ID and tasks
+func (s *SQLiteStorage) InsertProject(project models.Project) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertProject(tx, project); err != nil {
+		return err
+	}
+	for _, t := range project.Tasks {
+		t.ProjectID = project.ID
+		if err := insertTask(tx, t); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// InsertTask puts back a task exactly as given, keeping its ID
+func (s *SQLiteStorage) InsertTask(task models.Task) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(tx, task); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetProjects returns all projects outside the archive and the trash sorted
+// by status (active first)
+func (s *SQLiteStorage) GetProjects() []models.Project {
+	projects, err := s.queryProjectsWithTasks("deleted_at = '' AND archived_at = ''")
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return sortProjects(projects)
+}
+
+// GetArchivedProjects returns the archived projects outside the trash sorted
+// by status (active first)
+func (s *SQLiteStorage) GetArchivedProjects() []models.Project {
+	projects, err := s.queryProjectsWithTasks("deleted_at = '' AND archived_at != ''")
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return sortProjects(projects)
+}
+
+// queryProjectsWithTasks loads the projects matching where together with
+// their tasks outside the trash
+func (s *SQLiteStorage) queryProjectsWithTasks(where string) ([]models.Project, error) {
+	projects, err := s.queryProjects(where)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.queryTasks("deleted_at = '' AND project_id IN (SELECT id FROM projects WHERE " + where + ")")
+	if err != nil {
+		return nil, err
+	}
+	return attachTasks(projects, tasks), nil
+}
+
+// GetTasks returns all tasks outside the trash from projects that are not archived
+func (s *SQLiteStorage) GetTasks() []models.Task {
+	tasks, err := s.queryTasks("deleted_at = '' AND project_id IN (SELECT id FROM projects WHERE deleted_at = '' AND archived_at = '')")
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return tasks
+}
+
+// UpdateProjectStatus changes the status of a project
+func (s *SQLiteStorage) UpdateProjectStatus(projectID int, status string) error {
+	res, err := s.db.Exec("UPDATE projects SET status = ?, updated_at = ? WHERE id = ? AND deleted_at = ''",
+		status, formatTime(time.Now()), projectID)
+	return expectRow(res, err, "project not found")
+}
+
+// UpdateProject saves the editable fields of a project: name, client, billing,
+// cost or rate, currency and deadline
+func (s *SQLiteStorage) UpdateProject(project models.Project) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := linkClient(tx, &project); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE projects SET name = ?, client = ?, client_id = ?, cost = ?, billing = ?, rate = ?, currency = ?, deadline = ?,
+			updated_at = ? WHERE id = ? AND deleted_at = ''`,
+		project.Name, project.Client, project.ClientID, project.Cost, project.Billing, project.Rate, project.Currency,
+		project.Deadline, formatTime(time.Now()), project.ID,
+	)
+	if err := expectRow(res, err, "project not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateTask saves the editable fields of a task: title, description and deadline
+func (s *SQLiteStorage) UpdateTask(task models.Task) error {
+	res, err := s.db.Exec(
+		"UPDATE tasks SET title = ?, description = ?, deadline = ?, updated_at = ? WHERE "+liveTaskCondition,
+		task.Title, task.Description, task.Deadline, formatTime(time.Now()), task.ProjectID, task.ID,
+	)
+	return expectRow(res, err, "task not found")
+}
+
+// AddTimeEntry records time worked on a task and returns the entry ID. An
+// entry without an end starts the timer of the task
+func (s *SQLiteStorage) AddTimeEntry(projectID, taskID int, entry models.TimeEntry) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE "+liveTaskCondition+")", projectID, taskID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("task not found")
+	}
+	if entry.Running() {
+		var running bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM time_entries WHERE project_id = ? AND task_id = ? AND ended_at = '')",
+			projectID, taskID).Scan(&running); err != nil {
+			return 0, err
+		}
+		if running {
+			return 0, fmt.Errorf("a timer is already running on this task")
+		}
+	}
+
+	if entry.ID, err = nextSQLiteEntryID(tx, projectID, taskID); err != nil {
+		return 0, err
+	}
+	if err := insertTimeEntry(tx, projectID, taskID, entry); err != nil {
+		return 0, err
+	}
+	return entry.ID, tx.Commit()
+}
+
+// UpdateTimeEntry saves the start, end and note of a time entry, for example
+// to stop its timer. The invoice the entry was billed on is left alone, it
+// only changes with AddInvoice and DeleteInvoice
+func (s *SQLiteStorage) UpdateTimeEntry(projectID, taskID int, entry models.TimeEntry) error {
+	res, err := s.db.Exec(`UPDATE time_entries SET started_at = ?, ended_at = ?, note = ?
+		WHERE project_id = ? AND task_id = ? AND id = ?
+		AND task_id IN (SELECT id FROM tasks WHERE `+liveTaskCondition+`)`,
+		formatTime(entry.Start), formatOptionalTime(entry.End), entry.Note, projectID, taskID, entry.ID, projectID, taskID)
+	return expectRow(res, err, "time entry not found")
+}
+
+// DeleteTimeEntry permanently removes a time entry from a task. Entries
+// that have been invoiced cannot be deleted
+func (s *SQLiteStorage) DeleteTimeEntry(projectID, taskID, entryID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invoiceID int
+	err = tx.QueryRow(`SELECT invoice_id FROM time_entries WHERE project_id = ? AND task_id = ? AND id = ?
+		AND task_id IN (SELECT id FROM tasks WHERE `+liveTaskCondition+`)`,
+		projectID, taskID, entryID, projectID, taskID).Scan(&invoiceID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("time entry not found")
+	}
+	if err != nil {
+		return err
+	}
+	if invoiceID != 0 {
+		return fmt.Errorf("the time entry has been invoiced")
+	}
+
+	if _, err := tx.Exec("DELETE FROM time_entries WHERE project_id = ? AND task_id = ? AND id = ?",
+		projectID, taskID, entryID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetClients returns all clients sorted by name
+func (s *SQLiteStorage) GetClients() []models.Client {
+	clients, err := queryClients(s.db)
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	sortClients(clients)
+	return clients
+}
+
+// AddClient stores a new client and returns its ID
+func (s *SQLiteStorage) AddClient(client models.Client) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteClientName(tx, client); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) + 1 FROM clients").Scan(&client.ID); err != nil {
+		return 0, err
+	}
+	if err := insertClient(tx, client); err != nil {
+		return 0, err
+	}
+	return client.ID, tx.Commit()
+}
+
+// InsertClient puts back a client exactly as given, keeping its ID
+func (s *SQLiteStorage) InsertClient(client models.Client) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteClientName(tx, client); err != nil {
+		return err
+	}
+	if err := insertClient(tx, client); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateClient saves the details of a client. Its projects, including those
+// in the archive and the trash, take on the new name
+func (s *SQLiteStorage) UpdateClient(client models.Client) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteClientName(tx, client); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE clients SET name = ?, email = ?, phone = ?, address = ?, currency = ?, rate = ?, notes = ?
+			WHERE id = ?`,
+		client.Name, client.Email, client.Phone, client.Address, client.Currency, client.Rate, client.Notes, client.ID,
+	)
+	if err := expectRow(res, err, "client not found"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE projects SET client = ? WHERE client_id = ?", client.Name, client.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteClient removes a client that no project refers to, including
+// projects in the archive and the trash
+func (s *SQLiteStorage) DeleteClient(clientID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var used bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE client_id = ?)", clientID).Scan(&used); err != nil {
+		return err
+	}
+	if used {
+		return fmt.Errorf("the client still has projects")
+	}
+	res, err := tx.Exec("DELETE FROM clients WHERE id = ?", clientID)
+	if err := expectRow(res, err, "client not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// linkClient points a project at its client. A project that names a client
+// without its ID is linked to the client of that name, if there is one
+func linkClient(tx *sql.Tx, project *models.Project) error {
+	clients, err := queryClients(tx)
+	if err != nil {
+		return err
+	}
+	if project.ClientID == 0 {
+		if c := findClient(clients, project.Client); c != nil {
+			project.ClientID = c.ID
+			project.Client = c.Name
+		}
+		return nil
+	}
+	for _, c := range clients {
+		if c.ID == project.ClientID {
+			project.Client = c.Name
+			return nil
+		}
+	}
+	return fmt.Errorf("client not found")
+}
+
+// checkSQLiteClientName rejects clients without a name and names that
+// another client already goes by
+func checkSQLiteClientName(tx *sql.Tx, client models.Client) error {
+	clients, err := queryClients(tx)
+	if err != nil {
+		return err
+	}
+	return checkClientName(client, clients)
+}
+
+// GetInvoices returns all invoices in the order they were issued
+func (s *SQLiteStorage) GetInvoices() []models.Invoice {
+	invoices, err := s.queryInvoices()
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return invoices
+}
+
+// queryInvoices loads all invoices with their items in the order they were issued
+func (s *SQLiteStorage) queryInvoices() ([]models.Invoice, error) {
+	rows, err := s.db.Query("SELECT id, number, client, issue_date, due_date, tax_rate, currency, created_at FROM invoices ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []models.Invoice
+	index := make(map[int]int)
+	for rows.Next() {
+		var inv models.Invoice
+		var createdAt string
+		if err := rows.Scan(&inv.ID, &inv.Number, &inv.Client, &inv.IssueDate, &inv.DueDate, &inv.TaxRate, &inv.Currency, &createdAt); err != nil {
+			return nil, err
+		}
+		inv.CreatedAt = parseTime(createdAt)
+		index[inv.ID] = len(invoices)
+		invoices = append(invoices, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := s.db.Query(`SELECT invoice_id, description, quantity, unit, unit_price, project_id, entries
+		FROM invoice_items ORDER BY invoice_id, position`)
+	if err != nil {
+		return nil, err
+	}
+	defer items.Close()
+	for items.Next() {
+		var invoiceID int
+		var item models.InvoiceItem
+		var entries string
+		if err := items.Scan(&invoiceID, &item.Description, &item.Quantity, &item.Unit, &item.UnitPrice,
+			&item.ProjectID, &entries); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(entries), &item.Entries); err != nil {
+			return nil, err
+		}
+		if i, ok := index[invoiceID]; ok {
+			invoices[i].Items = append(invoices[i].Items, item)
+		}
+	}
+	return invoices, items.Err()
+}
+
+// AddInvoice stores an invoice under the next invoice number and marks the
+// work billed by its items as invoiced. It returns the invoice ID
+func (s *SQLiteStorage) AddInvoice(invoice models.Invoice) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if invoice.ID, err = nextSQLiteInvoiceID(tx); err != nil {
+		return 0, err
+	}
+	invoice.Number = invoiceNumber(invoice.ID)
+	invoice.CreatedAt = time.Now()
+
+	for _, item := range invoice.Items {
+		if len(item.Entries) == 0 {
+			res, err := tx.Exec("UPDATE projects SET invoice_id = ? WHERE id = ? AND invoice_id = 0 AND deleted_at = ''",
+				invoice.ID, item.ProjectID)
+			if err := expectRow(res, err, "project not found or already invoiced"); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		for _, ref := range item.Entries {
+			res, err := tx.Exec(`UPDATE time_entries SET invoice_id = ?
+				WHERE project_id = ? AND task_id = ? AND id = ? AND invoice_id = 0 AND ended_at != ''`,
+				invoice.ID, item.ProjectID, ref.TaskID, ref.EntryID)
+			if err := expectRow(res, err, "time entry not found or already invoiced"); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := insertInvoice(tx, invoice); err != nil {
+		return 0, err
+	}
+	return invoice.ID, tx.Commit()
+}
+
+// DeleteInvoice removes an invoice and marks the work it billed as unbilled again
+func (s *SQLiteStorage) DeleteInvoice(invoiceID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var paid bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM payments WHERE invoice_id = ?)", invoiceID).Scan(&paid); err != nil {
+		return err
+	}
+	if paid {
+		return fmt.Errorf("the invoice has payments recorded against it")
+	}
+	if _, err := tx.Exec("UPDATE projects SET invoice_id = 0 WHERE invoice_id = ?", invoiceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE time_entries SET invoice_id = 0 WHERE invoice_id = ?", invoiceID); err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM invoices WHERE id = ?", invoiceID)
+	if err := expectRow(res, err, "invoice not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPayments returns all payments in the order they were recorded
+func (s *SQLiteStorage) GetPayments() []models.Payment {
+	payments, err := s.queryPayments()
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return payments
+}
+
+// queryPayments loads all payments in the order they were recorded
+func (s *SQLiteStorage) queryPayments() ([]models.Payment, error) {
+	rows, err := s.db.Query("SELECT id, date, amount, method, project_id, invoice_id FROM payments ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		var pay models.Payment
+		if err := rows.Scan(&pay.ID, &pay.Date, &pay.Amount, &pay.Method, &pay.ProjectID, &pay.InvoiceID); err != nil {
+			return nil, err
+		}
+		payments = append(payments, pay)
+	}
+	return payments, rows.Err()
+}
+
+// AddPayment records a payment against an invoice or a project and returns its ID
+func (s *SQLiteStorage) AddPayment(payment models.Payment) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if payment.InvoiceID != 0 {
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM invoices WHERE id = ?)", payment.InvoiceID).Scan(&exists)
+	} else {
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = ? AND deleted_at = '')", payment.ProjectID).Scan(&exists)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !exists && payment.InvoiceID != 0 {
+		return 0, fmt.Errorf("invoice not found")
+	}
+	if !exists {
+		return 0, fmt.Errorf("project not found")
+	}
+
+	if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) + 1 FROM payments").Scan(&payment.ID); err != nil {
+		return 0, err
+	}
+	if err := insertPayment(tx, payment); err != nil {
+		return 0, err
+	}
+	return payment.ID, tx.Commit()
+}
+
+// DeletePayment removes a payment
+func (s *SQLiteStorage) DeletePayment(paymentID int) error {
+	res, err := s.db.Exec("DELETE FROM payments WHERE id = ?", paymentID)
+	return expectRow(res, err, "payment not found")
+}
+
+// GetExpenses returns all expenses sorted by date, oldest first
+func (s *SQLiteStorage) GetExpenses() []models.Expense {
+	expenses, err := s.queryExpenses()
+	if err != nil {
+		s.readFailed(err)
+		return nil
+	}
+	return expenses
+}
+
+// queryExpenses loads all expenses sorted by date, oldest first
+func (s *SQLiteStorage) queryExpenses() ([]models.Expense, error) {
+	rows, err := s.db.Query(
+		"SELECT id, date, amount, currency, category, note, receipt, project_id FROM expenses ORDER BY date, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.Expense
+	for rows.Next() {
+		var e models.Expense
+		if err := rows.Scan(&e.ID, &e.Date, &e.Amount, &e.Currency, &e.Category, &e.Note, &e.Receipt, &e.ProjectID); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, e)
+	}
+	return expenses, rows.Err()
+}
+
+// AddExpense records an expense of a project or of the business and returns its ID
+func (s *SQLiteStorage) AddExpense(expense models.Expense) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteExpenseProject(tx, expense); err != nil {
+		return 0, err
+	}
+	if err := tx.QueryRow("SELECT COALESCE(MAX(id), 0) + 1 FROM expenses").Scan(&expense.ID); err != nil {
+		return 0, err
+	}
+	if err := insertExpense(tx, expense); err != nil {
+		return 0, err
+	}
+	return expense.ID, tx.Commit()
+}
+
+// InsertExpense puts back an expense exactly as given, keeping its ID
+func (s *SQLiteStorage) InsertExpense(expense models.Expense) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteExpenseProject(tx, expense); err != nil {
+		return err
+	}
+	if err := insertExpense(tx, expense); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateExpense saves the details of an expense
+func (s *SQLiteStorage) UpdateExpense(expense models.Expense) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLiteExpenseProject(tx, expense); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE expenses SET date = ?, amount = ?, currency = ?, category = ?, note = ?, receipt = ?, project_id = ?
+			WHERE id = ?`,
+		expense.Date, expense.Amount, expense.Currency, expense.Category, expense.Note, expense.Receipt,
+		expense.ProjectID, expense.ID,
+	)
+	if err := expectRow(res, err, "expense not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExpense removes an expense
+func (s *SQLiteStorage) DeleteExpense(expenseID int) error {
+	res, err := s.db.Exec("DELETE FROM expenses WHERE id = ?", expenseID)
+	return expectRow(res, err, "expense not found")
+}
+
+// checkSQLiteExpenseProject makes sure an expense is for the business or a live project
+func checkSQLiteExpenseProject(tx *sql.Tx, expense models.Expense) error {
+	if expense.ProjectID == 0 {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE id = ? AND deleted_at = '')", expense.ProjectID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("project not found")
+	}
+	return nil
+}
+
+// SetProjectArchived moves a project into or out of the archive
+func (s *SQLiteStorage) SetProjectArchived(projectID int, archived bool) error {
+	archivedAt := ""
+	if archived {
+		archivedAt = formatTime(time.Now())
+	}
+	res, err := s.db.Exec(`UPDATE projects SET archived_at = CASE WHEN ? = '' OR archived_at = '' THEN ? ELSE archived_at END
+		WHERE id = ? AND deleted_at = ''`, archivedAt, archivedAt, projectID)
+	return expectRow(res, err, "project not found")
+}
+
+// DeleteProject moves a project and all its tasks to the trash
+func (s *SQLiteStorage) DeleteProject(projectID int) error {
+	res, err := s.db.Exec("UPDATE projects SET deleted_at = ? WHERE id = ? AND deleted_at = ''",
+		formatTime(time.Now()), projectID)
+	return expectRow(res, err, "project not found")
+}
+
+// UpdateTaskStatus changes the status of a task and updates completion date
+func (s *SQLiteStorage) UpdateTaskStatus(projectID, taskID int, status string, completedDate string) error {
+	if status != models.TaskStatusDone {
+		completedDate = ""
+	}
+	res, err := s.db.Exec(
+		"UPDATE tasks SET status = ?, completed_date = ?, updated_at = ? WHERE "+liveTaskCondition,
+		status, completedDate, formatTime(time.Now()), projectID, taskID,
+	)
+	return expectRow(res, err, "task not found")
+}
+
+// DeleteTask moves a task to the trash
+func (s *SQLiteStorage) DeleteTask(projectID, taskID int) error {
+	res, err := s.db.Exec("UPDATE tasks SET deleted_at = ? WHERE "+liveTaskCondition,
+		formatTime(time.Now()), projectID, taskID)
+	return expectRow(res, err, "task not found")
+}
+
+// GetTrash returns the projects in the trash together with all their tasks,
+// and the trashed tasks whose project is not in the trash
+func (s *SQLiteStorage) GetTrash() ([]models.Project, []models.Task) {
+	projects, tasks, err := s.queryTrash()
+	if err != nil {
+		s.readFailed(err)
+		return nil, nil
+	}
+	return projects, tasks
+}
+
+// queryTrash loads what GetTrash returns
+func (s *SQLiteStorage) queryTrash() ([]models.Project, []models.Task, error) {
+	projects, err := s.queryProjects("deleted_at != ''")
+	if err != nil {
+		return nil, nil, err
+	}
+	projectTasks, err := s.queryTasks("project_id IN (SELECT id FROM projects WHERE deleted_at != '')")
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := s.queryTasks("deleted_at != '' AND project_id IN (SELECT id FROM projects WHERE deleted_at = '')")
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachTasks(projects, projectTasks), tasks, nil
+}
+
+// RestoreProject takes a project out of the trash
+func (s *SQLiteStorage) RestoreProject(projectID int) error {
+	res, err := s.db.Exec("UPDATE projects SET deleted_at = '' WHERE id = ? AND deleted_at != ''", projectID)
+	return expectRow(res, err, "project not found in trash")
+}
+
+// RestoreTask takes a task out of the trash
+func (s *SQLiteStorage) RestoreTask(projectID, taskID int) error {
+	res, err := s.db.Exec(`UPDATE tasks SET deleted_at = '' WHERE project_id = ? AND id = ? AND deleted_at != ''
+		AND project_id IN (SELECT id FROM projects WHERE deleted_at = '')`, projectID, taskID)
+	return expectRow(res, err, "task not found in trash")
+}
+
+// PurgeProject permanently removes a project and all its tasks. Projects
+// that invoices, payments or expenses refer to cannot be purged
+func (s *SQLiteStorage) PurgeProject(projectID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLitePurgeProject(tx, projectID); err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM projects WHERE id = ?", projectID)
+	if err := expectRow(res, err, "project not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeTask permanently removes a task from its project. Tasks with
+// invoiced time cannot be purged
+func (s *SQLiteStorage) PurgeTask(projectID, taskID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkSQLitePurgeTask(tx, projectID, taskID); err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM tasks WHERE project_id = ? AND id = ?", projectID, taskID)
+	if err := expectRow(res, err, "task not found"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeTrash permanently removes projects and tasks that were moved to the
+// trash before the given time and returns how many entries were removed.
+// Projects and tasks that cannot be purged stay in the trash
+func (s *SQLiteStorage) PurgeTrash(before time.Time) (int, error) {
+	projects, tasks, err := s.queryTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	purged := 0
+	for _, p := range projects {
+		if !p.DeletedAt.Before(before) || checkSQLitePurgeProject(tx, p.ID) != nil {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", p.ID); err != nil {
+			return 0, err
+		}
+		purged++
+	}
+	for _, t := range tasks {
+		if !t.DeletedAt.Before(before) || checkSQLitePurgeTask(tx, t.ProjectID, t.ID) != nil {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM tasks WHERE project_id = ? AND id = ?", t.ProjectID, t.ID); err != nil {
+			return 0, err
+		}
+		purged++
+	}
+
+	return purged, tx.Commit()
+}
+
+// checkSQLitePurgeProject refuses to purge a project that invoices,
+// payments or expenses still refer to
+func checkSQLitePurgeProject(tx *sql.Tx, projectID int) error {
+	var invoiced, paid, spent bool
+	if err := tx.QueryRow(`SELECT
+		EXISTS(SELECT 1 FROM invoice_items WHERE project_id = ?),
+		EXISTS(SELECT 1 FROM payments WHERE project_id = ?),
+		EXISTS(SELECT 1 FROM expenses WHERE project_id = ?)`,
+		projectID, projectID, projectID).Scan(&invoiced, &paid, &spent); err != nil {
+		return err
+	}
+	switch {
+	case invoiced:
+		return fmt.Errorf("the project has been invoiced")
+	case paid:
+		return fmt.Errorf("the project has payments recorded against it")
+	case spent:
+		return fmt.Errorf("the project has expenses recorded against it")
+	}
+	return nil
+}
+
+// checkSQLitePurgeTask refuses to purge a task with invoiced time
+func checkSQLitePurgeTask(tx *sql.Tx, projectID, taskID int) error {
+	var invoiced bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM time_entries WHERE project_id = ? AND task_id = ? AND invoice_id != 0)",
+		projectID, taskID).Scan(&invoiced); err != nil {
+		return err
+	}
+	if invoiced {
+		return fmt.Errorf("the task has invoiced time")
+	}
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// queryClients loads all clients in the order they were added
+func queryClients(q querier) ([]models.Client, error) {
+	rows, err := q.Query("SELECT id, name, email, phone, address, currency, rate, notes FROM clients ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []models.Client
+	for rows.Next() {
+		var c models.Client
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Address, &c.Currency, &c.Rate, &c.Notes); err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+// liveTaskCondition matches a task by project and task ID when neither is in the trash
+const liveTaskCondition = `project_id = ? AND id = ? AND deleted_at = ''
+	AND project_id IN (SELECT id FROM projects WHERE deleted_at = '')`
+
+// queryProjects loads the projects matching where, without their tasks
+func (s *SQLiteStorage) queryProjects(where string, args ...any) ([]models.Project, error) {
+	rows, err := s.db.Query(`SELECT id, name, client, client_id, cost, billing, rate, currency, deadline, status,
+		created_at, updated_at, archived_at, deleted_at, invoice_id, last_task_id FROM projects WHERE `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var p models.Project
+		var createdAt, updatedAt, archivedAt, deletedAt string
+		if err := rows.Scan(&p.ID, &p.Name, &p.Client, &p.ClientID, &p.Cost, &p.Billing, &p.Rate, &p.Currency, &p.Deadline,
+			&p.Status, &createdAt, &updatedAt, &archivedAt, &deletedAt, &p.InvoiceID, &p.LastTaskID); err != nil {
+			return nil, err
+		}
+		p.CreatedAt = parseTime(createdAt)
+		p.UpdatedAt = parseTime(updatedAt)
+		p.ArchivedAt = parseOptionalTime(archivedAt)
+		p.DeletedAt = parseOptionalTime(deletedAt)
+		p.Tasks = make([]models.Task, 0)
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// queryTasks loads the tasks matching where
+func (s *SQLiteStorage) queryTasks(where string, args ...any) ([]models.Task, error) {
+	rows, err := s.db.Query(`SELECT project_id, id, title, description, deadline, status,
+		completed_date, created_at, updated_at, deleted_at, last_entry_id FROM tasks WHERE `+where+` ORDER BY project_id, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		var t models.Task
+		var createdAt, updatedAt, deletedAt string
+		if err := rows.Scan(&t.ProjectID, &t.ID, &t.Title, &t.Description, &t.Deadline, &t.Status,
+			&t.CompletedDate, &createdAt, &updatedAt, &deletedAt, &t.LastEntryID); err != nil {
+			return nil, err
+		}
+		t.CreatedAt = parseTime(createdAt)
+		t.UpdatedAt = parseTime(updatedAt)
+		t.DeletedAt = parseOptionalTime(deletedAt)
+		tasks = append(tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, s.attachTimeEntries(tasks, where, args...)
+}
+
+// attachTimeEntries loads the time entries of tasks, which are the tasks
+// matching where
+func (s *SQLiteStorage) attachTimeEntries(tasks []models.Task, where string, args ...any) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	rows, err := s.db.Query(`SELECT project_id, task_id, id, started_at, ended_at, note, invoice_id FROM time_entries
+		WHERE (project_id, task_id) IN (SELECT project_id, id FROM tasks WHERE `+where+`)
+		ORDER BY project_id, task_id, id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	index := make(map[[2]int]int, len(tasks))
+	for i, t := range tasks {
+		index[[2]int{t.ProjectID, t.ID}] = i
+	}
+	for rows.Next() {
+		var projectID, taskID int
+		var e models.TimeEntry
+		var start, end string
+		if err := rows.Scan(&projectID, &taskID, &e.ID, &start, &end, &e.Note, &e.InvoiceID); err != nil {
+			return err
+		}
+		e.Start = parseTime(start)
+		e.End = parseOptionalTime(end)
+		if i, ok := index[[2]int{projectID, taskID}]; ok {
+			tasks[i].TimeEntries = append(tasks[i].TimeEntries, e)
+		}
+	}
+	return rows.Err()
+}
+
+// attachTasks adds each task to its project in projects
+func attachTasks(projects []models.Project, tasks []models.Task) []models.Project {
+	index := make(map[int]int, len(projects))
+	for i, p := range projects {
+		index[p.ID] = i
+	}
+	for _, t := range tasks {
+		if i, ok := index[t.ProjectID]; ok {
+			projects[i].Tasks = append(projects[i].Tasks, t)
+		}
+	}
+	return projects
+}
+
+func insertProject(tx *sql.Tx, p models.Project) error {
+	_, err := tx.Exec(
+		`INSERT INTO projects (id, name, client, client_id, cost, billing, rate, currency, deadline, status,
+			created_at, updated_at, archived_at, deleted_at, invoice_id, last_task_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Name, p.Client, p.ClientID, p.Cost, p.Billing, p.Rate, p.Currency, p.Deadline, p.Status,
+		formatTime(p.CreatedAt), formatTime(p.UpdatedAt), formatOptionalTime(p.ArchivedAt), formatOptionalTime(p.DeletedAt),
+		p.InvoiceID, p.LastTaskID,
+	)
+	return err
+}
+
+func insertClient(tx *sql.Tx, c models.Client) error {
+	_, err := tx.Exec(
+		"INSERT INTO clients (id, name, email, phone, address, currency, rate, notes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		c.ID, c.Name, c.Email, c.Phone, c.Address, c.Currency, c.Rate, c.Notes,
+	)
+	return err
+}
+
+func insertTask(tx *sql.Tx, t models.Task) error {
+	_, err := tx.Exec(
+		`INSERT INTO tasks (project_id, id, title, description, deadline, status,
+			completed_date, created_at, updated_at, deleted_at, last_entry_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ProjectID, t.ID, t.Title, t.Description, t.Deadline, t.Status,
+		t.CompletedDate, formatTime(t.CreatedAt), formatTime(t.UpdatedAt), formatOptionalTime(t.DeletedAt), t.LastEntryID,
+	)
+	if err != nil {
+		return err
+	}
+	for _, e := range t.TimeEntries {
+		if err := insertTimeEntry(tx, t.ProjectID, t.ID, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertTimeEntry(tx *sql.Tx, projectID, taskID int, e models.TimeEntry) error {
+	_, err := tx.Exec(
+		"INSERT INTO time_entries (project_id, task_id, id, started_at, ended_at, note, invoice_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		projectID, taskID, e.ID, formatTime(e.Start), formatOptionalTime(e.End), e.Note, e.InvoiceID,
+	)
+	return err
+}
+
+func insertInvoice(tx *sql.Tx, inv models.Invoice) error {
+	_, err := tx.Exec(
+		"INSERT INTO invoices (id, number, client, issue_date, due_date, tax_rate, currency, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		inv.ID, inv.Number, inv.Client, inv.IssueDate, inv.DueDate, inv.TaxRate, inv.Currency, formatTime(inv.CreatedAt),
+	)
+	if err != nil {
+		return err
+	}
+	for i, item := range inv.Items {
+		entries, err := json.Marshal(item.Entries)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO invoice_items (invoice_id, position, description, quantity, unit, unit_price, project_id, entries)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			inv.ID, i, item.Description, item.Quantity, item.Unit, item.UnitPrice, item.ProjectID, string(entries),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertPayment(tx *sql.Tx, pay models.Payment) error {
+	_, err := tx.Exec(
+		"INSERT INTO payments (id, date, amount, method, project_id, invoice_id) VALUES (?, ?, ?, ?, ?, ?)",
+		pay.ID, pay.Date, pay.Amount, pay.Method, pay.ProjectID, pay.InvoiceID,
+	)
+	return err
+}
+
+func insertExpense(tx *sql.Tx, e models.Expense) error {
+	_, err := tx.Exec(
+		"INSERT INTO expenses (id, date, amount, currency, category, note, receipt, project_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		e.ID, e.Date, e.Amount, e.Currency, e.Category, e.Note, e.Receipt, e.ProjectID,
+	)
+	return err
+}
+
+// formatTime encodes a timestamp so that parsing it back yields the same instant and offset
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseTime decodes a timestamp written by formatTime
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// formatOptionalTime encodes a timestamp that may be unset as an empty string
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// parseOptionalTime decodes a timestamp written by formatOptionalTime
+func parseOptionalTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t := parseTime(s)
+	return &t
+}
+
+// expectRow turns a statement that touched no rows into a not-found error
+func expectRow(res sql.Result, err error, notFound string) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New(notFound)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"freelancy.go/internal/models"
+)
+
+func TestImportJSONRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	// Timestamps written by older versions carry nanoseconds and the offset
+	// of the zone they were made in
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	created := time.Date(2025, 11, 3, 9, 15, 42, 123456789, zone)
+	updated := time.Date(2026, 1, 20, 17, 5, 1, 987654321, time.UTC)
+	deleted := time.Date(2026, 2, 1, 8, 0, 0, 0, time.Local)
+	archived := time.Date(2026, 2, 10, 12, 30, 0, 500, time.Local)
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local)
+	end := start.Add(90 * time.Minute)
+
+	source := &Storage{dataFile: filepath.Join(dir, "data.json")}
+	source.MemoryStorage = MemoryStorage{
+		Clients: []models.Client{{ID: 1, Name: "Acme", Email: "billing@acme.test", Currency: "EUR", Rate: models.Cents(90)}},
+		Projects: []models.Project{
+			{ID: 1, Name: "Website", Client: "Acme", ClientID: 1, Status: "Completed", Billing: models.BillingHourly,
+				Rate: models.Cents(90), Currency: "EUR", Deadline: "2026-01-31", CreatedAt: created, UpdatedAt: updated,
+				LastTaskID: 3,
+				Tasks: []models.Task{
+					{ID: 1, ProjectID: 1, Title: "Design", Status: models.TaskStatusDone, CompletedDate: "2026-01-12",
+						CreatedAt: created, UpdatedAt: updated,
+						TimeEntries: []models.TimeEntry{{ID: 1, Start: start, End: &end, Note: "Mockups", InvoiceID: 1}}},
+					{ID: 3, ProjectID: 1, Title: "Launch", Status: models.TaskStatusWaiting,
+						CreatedAt: created, UpdatedAt: updated, DeletedAt: &deleted},
+				}},
+			{ID: 4, Name: "Old shop", Status: "Active", Billing: models.BillingFixed, Cost: models.Cents(1200),
+				Tasks: []models.Task{}, CreatedAt: created, UpdatedAt: created, ArchivedAt: &archived},
+			{ID: 5, Name: "Scrapped", Status: "Active", Billing: models.BillingFixed,
+				Tasks: []models.Task{}, CreatedAt: updated, UpdatedAt: updated, DeletedAt: &deleted},
+		},
+		Invoices: []models.Invoice{{ID: 1, Number: "INV-0001", Client: "Acme", IssueDate: "2026-01-31", DueDate: "2026-02-14",
+			TaxRate: 19, Currency: "EUR", CreatedAt: updated,
+			Items: []models.InvoiceItem{{Description: "Website: Design", Quantity: 1.5, Unit: "h", UnitPrice: models.Cents(90),
+				ProjectID: 1, Entries: []models.TimeEntryRef{{TaskID: 1, EntryID: 1}}}}}},
+		Payments:      []models.Payment{{ID: 1, Date: "2026-02-10", Amount: models.Cents(160.65), Method: "bank transfer", InvoiceID: 1}},
+		Expenses:      []models.Expense{{ID: 1, Date: "2026-01-08", Amount: models.Cents(25), Currency: "EUR", Category: "Software", ProjectID: 1}},
+		LastProjectID: 6,
+		LastInvoiceID: 3,
+	}
+	if err := source.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openSQLite(t, filepath.Join(dir, "data.db"))
+	count, err := db.ImportJSON(source.dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("imported %d projects, want 3", count)
+	}
+	if _, err := db.ImportJSON(source.dataFile); err == nil {
+		t.Error("imported into a database that has projects")
+	}
+
+	// Everything reads back from the database as it reads from data.json
+	reopened, err := NewFileStorage(source.dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snapshot(t, db), snapshot(t, reopened); got != want {
+		t.Errorf("imported data differs\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	website := db.GetProjects()[0]
+	if !website.CreatedAt.Equal(created) || !website.UpdatedAt.Equal(updated) {
+		t.Errorf("project created at %v and updated at %v, want %v and %v",
+			website.CreatedAt, website.UpdatedAt, created, updated)
+	}
+	design := website.Tasks[0]
+	if !design.CreatedAt.Equal(created) || !design.UpdatedAt.Equal(updated) || design.CompletedDate != "2026-01-12" {
+		t.Errorf("task created at %v, updated at %v and completed %q", design.CreatedAt, design.UpdatedAt, design.CompletedDate)
+	}
+	if e := design.TimeEntries[0]; !e.Start.Equal(start) || !e.End.Equal(end) || e.InvoiceID != 1 {
+		t.Errorf("time entry read back as %+v", e)
+	}
+	if archive := db.GetArchivedProjects(); len(archive) != 1 || !archive[0].ArchivedAt.Equal(archived) {
+		t.Errorf("archive read back as %+v", archive)
+	}
+	projects, tasks := db.GetTrash()
+	if len(projects) != 1 || !projects[0].DeletedAt.Equal(deleted) || len(tasks) != 1 || !tasks[0].DeletedAt.Equal(deleted) {
+		t.Errorf("trash read back as %+v and %+v", projects, tasks)
+	}
+
+	// The IDs handed out before the import are not handed out again
+	if id, err := db.AddProject(models.Project{Name: "Next"}); err != nil || id != 7 {
+		t.Errorf("new project got ID %d (%v), want 7", id, err)
+	}
+	if id, err := db.AddTask(1, models.Task{Title: "Next"}); err != nil || id != 4 {
+		t.Errorf("new task got ID %d (%v), want 4", id, err)
+	}
+	inv, err := db.AddInvoice(models.Invoice{Client: "Acme", Currency: "EUR",
+		Items: []models.InvoiceItem{{Description: "Old shop", Quantity: 1, UnitPrice: models.Cents(1200), ProjectID: 4}}})
+	if err != nil || inv != 4 {
+		t.Errorf("new invoice got ID %d (%v), want 4", inv, err)
+	}
+	checkNoReadError(t, db)
+}
@@ -1,194 +1,515 @@
 package storage
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"freelancy.go/internal/models"
 )
 
-// Storage handles data persistence for projects and tasks
+// Storage persists projects and tasks in a JSON file
 type Storage struct {
+	SchemaVersion int `json:"schema_version"`
+	MemoryStorage
 	dataFile string
-	Projects []models.Project `json:"projects"`
+	upgraded bool
+	hashMu   sync.Mutex
+	fileHash [sha256.Size]byte
+	warnings []string
 }
 
+// ErrConflict is returned when a change could not be applied because another
+// freelancy instance modified the same data in the meantime
+var ErrConflict = errors.New("data was changed by another freelancy instance")
+
 // NewStorage creates a new storage instance and initializes the data file
 func NewStorage() (*Storage, error) {
-	homeDir, err := os.UserHomeDir()
+	dataDir, err := DataDir()
 	if err != nil {
 		return nil, err
 	}
 
+	return NewFileStorage(filepath.Join(dataDir, "data.json"))
+}
+
+// DataDir returns the freelancy data directory, creating it if needed
+func DataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
 	dataDir := filepath.Join(homeDir, ".freelancy")
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	dataFile := filepath.Join(dataDir, "data.json")
+	return dataDir, nil
+}
+
+// NewFileStorage creates a storage instance backed by the given JSON file.
+// If the file is damaged, the last good copy from the backup is used instead
+func NewFileStorage(dataFile string) (*Storage, error) {
 	storage := &Storage{
 		dataFile: dataFile,
 	}
 
-	if err := storage.Load(); err != nil {
-		if !os.IsNotExist(err) {
-			return nil, err
-		}
-		storage.Projects = make([]models.Project, 0)
-		if err := storage.Save(); err != nil {
-			return nil, err
-		}
+	lock, err := lockFile(storage.lockFile())
+	if err != nil {
+		return nil, err
 	}
+	defer lock.unlock()
 
+	if err := storage.open(); err != nil {
+		return nil, err
+	}
 	return storage, nil
 }
 
+// open loads the data file, creating it or recovering it from the backup as needed
+func (s *Storage) open() error {
+	err := s.Load()
+	if err == nil {
+		if s.upgraded {
+			return s.Save()
+		}
+		return nil
+	}
+
+	if os.IsNotExist(err) {
+		s.MemoryStorage = *NewMemoryStorage()
+		return s.Save()
+	}
+	if errors.Is(err, ErrNewerSchema) {
+		return err
+	}
+
+	if bakErr := s.loadFile(s.dataFile + ".bak"); bakErr != nil {
+		return fmt.Errorf("%s is unreadable (%v) and no usable backup was found: %v", s.dataFile, err, bakErr)
+	}
+
+	corruptFile := s.dataFile + ".corrupt"
+	if data, readErr := os.ReadFile(s.dataFile); readErr == nil {
+		os.WriteFile(corruptFile, data, 0644)
+	}
+	s.warnings = append(s.warnings, fmt.Sprintf(
+		"%s could not be read (%v); restored data from %s.bak, damaged file kept as %s",
+		filepath.Base(s.dataFile), err, filepath.Base(s.dataFile), filepath.Base(corruptFile),
+	))
+	return s.Save()
+}
+
+// Warnings returns problems that were recovered from while opening the storage
+func (s *Storage) Warnings() []string {
+	return s.warnings
+}
+
 // Load reads data from the storage file
 func (s *Storage) Load() error {
-	data, err := os.ReadFile(s.dataFile)
+	return s.loadFile(s.dataFile)
+}
+
+// loadFile replaces the in-memory data with the contents of path, leaving it
+// untouched if the file cannot be read or parsed
+func (s *Storage) loadFile(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, s)
+	return s.decode(data)
 }
 
-// Save writes data to the storage file
-func (s *Storage) Save() error {
-	data, err := json.MarshalIndent(s, "", "  ")
+// decode parses the contents of the data file and remembers its hash. Files
+// written with an older schema are upgraded, backing up each version first
+func (s *Storage) decode(data []byte) error {
+	upgraded, migrated, err := upgradeData(data, func(version int, old []byte) error {
+		return writeFileAtomic(fmt.Sprintf("%s.v%d.bak", s.dataFile, version), old, 0644)
+	})
 	if err != nil {
 		return err
 	}
 
-	file, err := os.OpenFile(s.dataFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	var loaded MemoryStorage
+	if err := json.Unmarshal(upgraded, &loaded); err != nil {
+		return err
+	}
+	s.MemoryStorage = loaded
+	s.upgraded = migrated
+	s.setFileHash(data)
+	return nil
+}
+
+// Save writes data to the storage file. The new contents are written to a
+// temporary file and renamed over the original, keeping the previous version as a backup
+func (s *Storage) Save() error {
+	s.SchemaVersion = SchemaVersion
+	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	_, err = file.Write(data)
-	return err
+	if err := s.backup(); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.dataFile, data, 0644); err != nil {
+		return err
+	}
+	s.upgraded = false
+	s.setFileHash(data)
+	return nil
 }
 
-// AddProject adds a new project to storage
-func (s *Storage) AddProject(project models.Project) error {
-	if len(s.Projects) == 0 {
-		project.ID = 1
-	} else {
-		project.ID = s.Projects[len(s.Projects)-1].ID + 1
+// backup copies the data file to the .bak file if it holds what this
+// instance last read or wrote. A file that could not be decoded, or was
+// changed behind its back, never replaces the last good copy
+func (s *Storage) backup() error {
+	data, err := os.ReadFile(s.dataFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
 	}
-	if project.Status == "" {
-		project.Status = "Active"
+	if !s.matchesFileHash(data) {
+		return nil
 	}
-	s.Projects = append(s.Projects, project)
-	return s.Save()
+	return writeFileAtomic(s.dataFile+".bak", data, 0644)
 }
 
-// AddTask adds a new task to the specified project
-func (s *Storage) AddTask(projectID int, task models.Task) error {
-	for i, p := range s.Projects {
-		if p.ID == projectID {
-			if len(p.Tasks) == 0 {
-				task.ID = 1
-			} else {
-				task.ID = p.Tasks[len(p.Tasks)-1].ID + 1
-			}
-			task.ProjectID = projectID
-			task.CreatedAt = time.Now()
-			task.UpdatedAt = time.Now()
-			s.Projects[i].Tasks = append(s.Projects[i].Tasks, task)
-			return s.Save()
+// setFileHash remembers the contents last read from or written to the data file
+func (s *Storage) setFileHash(data []byte) {
+	s.hashMu.Lock()
+	defer s.hashMu.Unlock()
+	s.fileHash = sha256.Sum256(data)
+}
+
+// matchesFileHash reports whether data is what this instance last read or wrote
+func (s *Storage) matchesFileHash(data []byte) bool {
+	s.hashMu.Lock()
+	defer s.hashMu.Unlock()
+	return sha256.Sum256(data) == s.fileHash
+}
+
+// lockFile returns the path of the lock file guarding the data file
+func (s *Storage) lockFile() string {
+	return s.dataFile + ".lock"
+}
+
+// reloadIfChanged rereads the data file if another process has written it
+// since this instance last loaded or saved it, and reports whether it did
+func (s *Storage) reloadIfChanged() (bool, error) {
+	data, err := os.ReadFile(s.dataFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
 		}
+		return false, err
 	}
-	return nil
+	if s.matchesFileHash(data) {
+		return false, nil
+	}
+
+	if err := s.decode(data); err != nil {
+		return false, fmt.Errorf("%w: %s cannot be read: %v", ErrConflict, filepath.Base(s.dataFile), err)
+	}
+	return true, nil
 }
 
-// GetProjects returns all projects sorted by status (active first)
-func (s *Storage) GetProjects() []models.Project {
-	var activeProjects []models.Project
-	var completedProjects []models.Project
+// update applies change while holding the data file lock. Changes made by
+// other freelancy instances are loaded first, so change is replayed on top of
+// them instead of overwriting them
+func (s *Storage) update(change func() error) error {
+	lock, err := lockFile(s.lockFile())
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
 
-	for _, project := range s.Projects {
-		if project.Status == "Completed" {
-			completedProjects = append(completedProjects, project)
-		} else {
-			activeProjects = append(activeProjects, project)
+	reloaded, err := s.reloadIfChanged()
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		if reloaded {
+			return fmt.Errorf("%w: %v", ErrConflict, err)
 		}
+		return err
 	}
+	return s.Save()
+}
+
+// AddProject adds a new project to storage and returns its ID
+func (s *Storage) AddProject(project models.Project) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddProject(project)
+		return err
+	})
+	return id, err
+}
 
-	sortedProjects := make([]models.Project, 0, len(s.Projects))
-	sortedProjects = append(sortedProjects, activeProjects...)
-	sortedProjects = append(sortedProjects, completedProjects...)
+// AddTask adds a new task to the specified project and returns its ID
+func (s *Storage) AddTask(projectID int, task models.Task) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddTask(projectID, task)
+		return err
+	})
+	return id, err
+}
 
-	return sortedProjects
+// InsertProject puts back a project exactly as given, keeping its ID and tasks
+func (s *Storage) InsertProject(project models.Project) error {
+	return s.update(func() error {
+		return s.MemoryStorage.InsertProject(project)
+	})
 }
 
-// GetTasks returns all tasks from all projects
-func (s *Storage) GetTasks() []models.Task {
-	var allTasks []models.Task
-	for _, p := range s.Projects {
-		allTasks = append(allTasks, p.Tasks...)
-	}
-	return allTasks
+// InsertTask puts back a task exactly as given, keeping its ID
+func (s *Storage) InsertTask(task models.Task) error {
+	return s.update(func() error {
+		return s.MemoryStorage.InsertTask(task)
+	})
 }
 
 // UpdateProjectStatus changes the status of a project
 func (s *Storage) UpdateProjectStatus(projectID int, status string) error {
-	for i, p := range s.Projects {
-		if p.ID == projectID {
-			s.Projects[i].Status = status
-			return s.Save()
-		}
-	}
-	return fmt.Errorf("project not found")
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateProjectStatus(projectID, status)
+	})
+}
+
+// UpdateProject saves the editable fields of a project: name, client, billing,
+// cost or rate, currency and deadline
+func (s *Storage) UpdateProject(project models.Project) error {
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateProject(project)
+	})
+}
+
+// UpdateTask saves the editable fields of a task: title, description and deadline
+func (s *Storage) UpdateTask(task models.Task) error {
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateTask(task)
+	})
+}
+
+// AddTimeEntry records time worked on a task and returns the entry ID
+func (s *Storage) AddTimeEntry(projectID, taskID int, entry models.TimeEntry) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddTimeEntry(projectID, taskID, entry)
+		return err
+	})
+	return id, err
+}
+
+// UpdateTimeEntry saves the start, end and note of a time entry, leaving
+// the invoice it was billed on alone
+func (s *Storage) UpdateTimeEntry(projectID, taskID int, entry models.TimeEntry) error {
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateTimeEntry(projectID, taskID, entry)
+	})
+}
+
+// DeleteTimeEntry permanently removes a time entry from a task. Entries
+// that have been invoiced cannot be deleted
+func (s *Storage) DeleteTimeEntry(projectID, taskID, entryID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteTimeEntry(projectID, taskID, entryID)
+	})
+}
+
+// AddClient stores a new client and returns its ID
+func (s *Storage) AddClient(client models.Client) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddClient(client)
+		return err
+	})
+	return id, err
+}
+
+// InsertClient puts back a client exactly as given, keeping its ID
+func (s *Storage) InsertClient(client models.Client) error {
+	return s.update(func() error {
+		return s.MemoryStorage.InsertClient(client)
+	})
+}
+
+// UpdateClient saves the details of a client and renames its projects with it
+func (s *Storage) UpdateClient(client models.Client) error {
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateClient(client)
+	})
 }
 
-// DeleteProject removes a project and all its tasks
+// DeleteClient removes a client that no project refers to
+func (s *Storage) DeleteClient(clientID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteClient(clientID)
+	})
+}
+
+// AddInvoice stores an invoice, marks the work it bills as invoiced and
+// returns the invoice ID
+func (s *Storage) AddInvoice(invoice models.Invoice) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddInvoice(invoice)
+		return err
+	})
+	return id, err
+}
+
+// DeleteInvoice removes an invoice and marks the work it billed as unbilled again
+func (s *Storage) DeleteInvoice(invoiceID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteInvoice(invoiceID)
+	})
+}
+
+// AddPayment records a payment against an invoice or a project and returns its ID
+func (s *Storage) AddPayment(payment models.Payment) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddPayment(payment)
+		return err
+	})
+	return id, err
+}
+
+// DeletePayment removes a payment
+func (s *Storage) DeletePayment(paymentID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.DeletePayment(paymentID)
+	})
+}
+
+// AddExpense records an expense of a project or of the business and returns its ID
+func (s *Storage) AddExpense(expense models.Expense) (int, error) {
+	var id int
+	err := s.update(func() (err error) {
+		id, err = s.MemoryStorage.AddExpense(expense)
+		return err
+	})
+	return id, err
+}
+
+// InsertExpense puts back an expense exactly as given, keeping its ID
+func (s *Storage) InsertExpense(expense models.Expense) error {
+	return s.update(func() error {
+		return s.MemoryStorage.InsertExpense(expense)
+	})
+}
+
+// UpdateExpense saves the details of an expense
+func (s *Storage) UpdateExpense(expense models.Expense) error {
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateExpense(expense)
+	})
+}
+
+// DeleteExpense removes an expense
+func (s *Storage) DeleteExpense(expenseID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteExpense(expenseID)
+	})
+}
+
+// SetProjectArchived moves a project into or out of the archive
+func (s *Storage) SetProjectArchived(projectID int, archived bool) error {
+	return s.update(func() error {
+		return s.MemoryStorage.SetProjectArchived(projectID, archived)
+	})
+}
+
+// DeleteProject moves a project and all its tasks to the trash
 func (s *Storage) DeleteProject(projectID int) error {
-	for i, p := range s.Projects {
-		if p.ID == projectID {
-			s.Projects = append(s.Projects[:i], s.Projects[i+1:]...)
-			return s.Save()
-		}
-	}
-	return fmt.Errorf("project not found")
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteProject(projectID)
+	})
 }
 
 // UpdateTaskStatus changes the status of a task and updates completion date
 func (s *Storage) UpdateTaskStatus(projectID, taskID int, status string, completedDate string) error {
-	for i, p := range s.Projects {
-		if p.ID == projectID {
-			for j, t := range p.Tasks {
-				if t.ID == taskID {
-					s.Projects[i].Tasks[j].Status = status
-					s.Projects[i].Tasks[j].UpdatedAt = time.Now()
-					if status == models.TaskStatusDone {
-						s.Projects[i].Tasks[j].CompletedDate = completedDate
-					} else {
-						s.Projects[i].Tasks[j].CompletedDate = ""
-					}
-					return s.Save()
-				}
-			}
-		}
-	}
-	return fmt.Errorf("task not found")
+	return s.update(func() error {
+		return s.MemoryStorage.UpdateTaskStatus(projectID, taskID, status, completedDate)
+	})
 }
 
-// DeleteTask removes a task from its project
+// DeleteTask moves a task to the trash
 func (s *Storage) DeleteTask(projectID, taskID int) error {
-	for i, p := range s.Projects {
-		if p.ID == projectID {
-			for j, t := range p.Tasks {
-				if t.ID == taskID {
-					s.Projects[i].Tasks = append(s.Projects[i].Tasks[:j], s.Projects[i].Tasks[j+1:]...)
-					return s.Save()
-				}
-			}
+	return s.update(func() error {
+		return s.MemoryStorage.DeleteTask(projectID, taskID)
+	})
+}
+
+// RestoreProject takes a project out of the trash
+func (s *Storage) RestoreProject(projectID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.RestoreProject(projectID)
+	})
+}
+
+// RestoreTask takes a task out of the trash
+func (s *Storage) RestoreTask(projectID, taskID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.RestoreTask(projectID, taskID)
+	})
+}
+
+// PurgeProject permanently removes a project and all its tasks unless
+// invoices, payments or expenses refer to it
+func (s *Storage) PurgeProject(projectID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.PurgeProject(projectID)
+	})
+}
+
+// PurgeTask permanently removes a task from its project unless its time
+// has been invoiced
+func (s *Storage) PurgeTask(projectID, taskID int) error {
+	return s.update(func() error {
+		return s.MemoryStorage.PurgeTask(projectID, taskID)
+	})
+}
+
+// PurgeTrash permanently removes projects and tasks that were moved to the
+// trash before the given time and returns how many entries were removed
+func (s *Storage) PurgeTrash(before time.Time) (int, error) {
+	if !s.hasTrashBefore(before) {
+		return 0, nil
+	}
+
+	var purged int
+	err := s.update(func() (err error) {
+		purged, err = s.MemoryStorage.PurgeTrash(before)
+		return err
+	})
+	return purged, err
+}
+
+// hasTrashBefore reports whether anything in the trash is due for purging,
+// so that startup does not rewrite the data file when there is nothing to do
+func (s *Storage) hasTrashBefore(before time.Time) bool {
+	projects, tasks := s.GetTrash()
+	for _, p := range projects {
+		if p.DeletedAt.Before(before) && s.checkPurgeProject(p.ID) == nil {
+			return true
+		}
+	}
+	for _, t := range tasks {
+		if t.DeletedAt.Before(before) && checkPurgeTask(t) == nil {
+			return true
 		}
 	}
-	return fmt.Errorf("task not found")
-} 
\ No newline at end of file
+	return false
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"freelancy.go/internal/models"
+)
+
+// openFile opens the JSON storage in file, failing the test on errors
+func openFile(t *testing.T, file string) *Storage {
+	t.Helper()
+	s, err := NewFileStorage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// projectNames returns the names of the projects of repo in order
+func projectNames(repo Repository) string {
+	var names []string
+	for _, p := range repo.GetProjects() {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkBackup checks that the backup of dataFile can be read and holds the
+// projects named
+func checkBackup(t *testing.T, dataFile, names string) {
+	t.Helper()
+	data, err := os.ReadFile(dataFile + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup Storage
+	if err := backup.decode(data); err != nil {
+		t.Fatalf("backup cannot be read: %v", err)
+	}
+	if got := projectNames(&backup); got != names {
+		t.Errorf("backup holds %q, want %q", got, names)
+	}
+}
+
+func TestOpenRecoversFromBackup(t *testing.T) {
+	tests := []struct {
+		name    string
+		damaged string
+	}{
+		{"truncated", `{"projects": [{"id": 1, "na`},
+		{"undecodable", fmt.Sprintf(`{"schema_version": %d, "projects": "Website"}`, SchemaVersion)},
+		{"undecodable before upgrading", `{"projects": "Website"}`},
+		{"empty", ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dataFile := filepath.Join(dir, "data.json")
+			s := openFile(t, dataFile)
+			must := mustAdd(t)
+			must(s.AddProject(models.Project{Name: "Website"}))
+			// The backup holds the file as it was before the last save
+			must(s.AddProject(models.Project{Name: "Shop"}))
+			if err := os.WriteFile(dataFile, []byte(tt.damaged), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			recovered := openFile(t, dataFile)
+			if names := projectNames(recovered); names != "Website" {
+				t.Fatalf("recovered projects %q, want the backup", names)
+			}
+			if w := recovered.Warnings(); len(w) != 1 || !strings.Contains(w[0], "restored data from data.json.bak") {
+				t.Errorf("warnings %q", w)
+			}
+			if corrupt, _ := os.ReadFile(dataFile + ".corrupt"); string(corrupt) != tt.damaged {
+				t.Errorf("damaged file kept as %q", corrupt)
+			}
+
+			// Saving never puts the damaged file in place of the backup
+			checkBackup(t, dataFile, "Website")
+			must(recovered.AddProject(models.Project{Name: "Next"}))
+			checkBackup(t, dataFile, "Website")
+			must(recovered.AddProject(models.Project{Name: "Last"}))
+			checkBackup(t, dataFile, "Website, Next")
+			if names := projectNames(openFile(t, dataFile)); names != "Website, Next, Last" {
+				t.Errorf("reopened projects %q", names)
+			}
+
+			// Saves go through a temporary file that is renamed into place
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if strings.HasSuffix(e.Name(), ".tmp") {
+					t.Errorf("temporary file %s left behind", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestOpenWithoutUsableBackup(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	damaged := []byte(`{"projects": [`)
+	if err := os.WriteFile(dataFile, damaged, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStorage(dataFile); err == nil || !strings.Contains(err.Error(), "no usable backup") {
+		t.Fatalf("opened a damaged file without a backup: %v", err)
+	}
+	if data, _ := os.ReadFile(dataFile); !bytes.Equal(data, damaged) {
+		t.Error("the damaged file was overwritten")
+	}
+}
+
+func TestInstancesSharingAFile(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.json")
+	a, b := openFile(t, dataFile), openFile(t, dataFile)
+	must := mustAdd(t)
+
+	// Each change is replayed on top of what the other instance saved
+	website := must(a.AddProject(models.Project{Name: "Website"}))
+	shop := must(b.AddProject(models.Project{Name: "Shop"}))
+	if website == shop {
+		t.Fatalf("both instances handed out project ID %d", shop)
+	}
+	design := must(a.AddTask(shop, models.Task{Title: "Design"}))
+	if names := projectNames(b); names != "Website, Shop" {
+		t.Errorf("second instance sees %q before its next change", names)
+	}
+	if err := b.UpdateTaskStatus(shop, design, models.TaskStatusDone, "2026-03-01"); err != nil {
+		t.Fatalf("changing a task added by the other instance: %v", err)
+	}
+
+	// A change to a task the other instance has removed is refused
+	if err := a.DeleteTask(shop, design); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.PurgeTask(shop, design); err != nil {
+		t.Fatal(err)
+	}
+	err := b.UpdateTask(models.Task{ProjectID: shop, ID: design, Title: "Design v2"})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("updating a purged task returned %v, want ErrConflict", err)
+	}
+	// Without changes from the other instance the error is an ordinary one
+	err = b.UpdateTask(models.Task{ProjectID: shop, ID: design, Title: "Design v2"})
+	if err == nil || errors.Is(err, ErrConflict) {
+		t.Errorf("second update returned %v, want task not found", err)
+	}
+
+	// Neither instance loses the other's changes when both save at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i, s := range []*Storage{a, b} {
+		wg.Add(1)
+		go func(i int, s *Storage) {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				if _, err := s.AddProject(models.Project{Name: fmt.Sprintf("%d-%d", i, n)}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	projects := openFile(t, dataFile).GetProjects()
+	ids := make(map[int]bool)
+	for _, p := range projects {
+		if ids[p.ID] {
+			t.Errorf("project ID %d handed out twice", p.ID)
+		}
+		ids[p.ID] = true
+	}
+	if len(projects) != 42 {
+		t.Errorf("%d projects saved, want 42", len(projects))
+	}
+}
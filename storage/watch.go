@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchInterval is how often the data file is checked for outside changes
+const watchInterval = time.Second
+
+// DataChangedMsg is sent to the Bubble Tea program when the data file was
+// modified by another process, a script or a sync tool
+type DataChangedMsg struct{}
+
+// Watcher is implemented by backends that can notice changes made to their
+// data outside of this process
+type Watcher interface {
+	// Watch returns a command that blocks until the data changes on disk
+	// and then produces a DataChangedMsg
+	Watch() tea.Cmd
+	// Reload replaces the in-memory data with what is currently on disk
+	Reload() error
+}
+
+var _ Watcher = (*Storage)(nil)
+
+// Watch returns a command that polls the data file and produces a
+// DataChangedMsg once its contents differ from what this instance last saw
+func (s *Storage) Watch() tea.Cmd {
+	return func() tea.Msg {
+		var lastMod time.Time
+		var lastSize int64 = -1
+		for {
+			time.Sleep(watchInterval)
+
+			info, err := os.Stat(s.dataFile)
+			if err != nil {
+				continue
+			}
+			if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+
+			data, err := os.ReadFile(s.dataFile)
+			if err != nil {
+				continue
+			}
+			if !s.matchesFileHash(data) {
+				return DataChangedMsg{}
+			}
+		}
+	}
+}
+
+// Reload replaces the in-memory data with the current contents of the data file
+func (s *Storage) Reload() error {
+	lock, err := lockFile(s.lockFile())
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	_, err = s.reloadIfChanged()
+	return err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/models"
+	"freelancy.go/internal/tax"
+)
+
+// vatReceived adds up the tax paid on invoices by the month it was received
+// in, in the reporting currency
+func (m model) vatReceived() map[string]models.Money {
+	vat := make(map[string]models.Money)
+	for _, inv := range m.storage.GetInvoices() {
+		for _, r := range m.ledger.TaxReceived(inv) {
+			if amount, ok := m.reporting(r.Amount, inv.Currency, r.Date); ok {
+				vat[r.Date.Format("Jan 2006")] += amount
+			}
+		}
+	}
+	return vat
+}
+
+// taxMonths returns the profit of each month from January of the year
+// twelve months before now up to now, and the VAT received in it. The
+// income of the cash basis includes the VAT paid with invoices, which is
+// not profit
+func (m model) taxMonths(now time.Time) []tax.Month {
+	vat := m.vatReceived()
+	var months []tax.Month
+	start := time.Date(now.AddDate(0, -11, 0).Year(), 1, 1, 0, 0, 0, 0, now.Location())
+	for _, mi := range m.incomeChart.MonthsSince(start) {
+		month := tax.Month{Start: mi.Start, Profit: mi.Net(), VAT: vat[mi.Period].Float()}
+		if m.incomeChart.Cash() {
+			month.Profit -= month.VAT
+		}
+		months = append(months, month)
+	}
+	return months
+}
+
+// renderTaxPanel shows the income tax and VAT to set aside for each of the
+// last twelve months, each quarter and the year to date
+func (m model) renderTaxPanel() string {
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	quarterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	now := time.Now()
+	s := headerStyle.Render(fmt.Sprintf("Taxes to set aside, on profit %s (income tax %d: %s, VAT from invoices paid)",
+		m.incomeChart.Basis(), now.Year(), m.config.IncomeTax.Describe(now.Year()))) + "\n\n"
+
+	row := "%-14s %14s %14s %14s %14s"
+	s += headerStyle.Render(fmt.Sprintf(row, "Period", "Profit", "Income tax", "VAT", "Set aside")) + "\n"
+	format := func(l tax.Liability) string {
+		return fmt.Sprintf(row, l.Period,
+			m.formatReporting(models.Cents(l.Profit)),
+			m.formatReporting(models.Cents(l.IncomeTax)),
+			m.formatReporting(models.Cents(l.VAT)),
+			m.formatReporting(models.Cents(l.SetAside())))
+	}
+
+	// The tax of a month depends on the profit of its year before it, so
+	// the estimate starts in January even though only twelve months are shown
+	months := tax.Estimate(m.config.IncomeTax, m.taxMonths(now))
+	shown := months[max(0, len(months)-12):]
+	for _, mo := range shown {
+		s += format(mo) + "\n"
+	}
+	s += "\n"
+	for _, q := range tax.Quarters(months) {
+		if len(shown) > 0 && q.Start.AddDate(0, 3, 0).After(shown[0].Start) {
+			s += quarterStyle.Render(format(q)) + "\n"
+		}
+	}
+	s += headerStyle.Render(format(tax.YearToDate(months, now))) + "\n"
+	return s
+}
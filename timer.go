@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+	"freelancy.go/ui"
+)
+
+// timerTickMsg redraws the running timer every second
+type timerTickMsg struct{}
+
+func tickTimer() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return timerTickMsg{}
+	})
+}
+
+// runningTimer finds the task whose timer is running, if any
+func (m model) runningTimer() (models.Task, models.TimeEntry, bool) {
+	for _, t := range m.taskTable.tasks {
+		if e, ok := t.RunningEntry(); ok {
+			return t, e, true
+		}
+	}
+	return models.Task{}, models.TimeEntry{}, false
+}
+
+// toggleTimer starts the timer on a task, stopping the one running on another
+// task first, or asks for a note and stops it if it is already running
+func (m model) toggleTimer(task models.Task) (tea.Model, tea.Cmd) {
+	now := time.Now()
+
+	if entry, ok := task.RunningEntry(); ok {
+		m.stopping = &history.StopTimer{Task: task, Entry: entry, End: now}
+		m.timerPrompt = ui.NewPrompt(
+			fmt.Sprintf("Stop timer on '%s' after %s", task.Title, formatDuration(entry.Duration(now))),
+			"Note (optional)",
+		)
+		m.activeView = "stop_timer"
+		return m, m.timerPrompt.Init()
+	}
+
+	var cmds []tea.Cmd
+	if running, entry, ok := m.runningTimer(); ok {
+		cmds = append(cmds, m.execute(&history.StopTimer{Task: running, Entry: entry, End: now},
+			fmt.Sprintf("Timer stopped on '%s'", running.Title)))
+	}
+	cmds = append(cmds, m.execute(&history.StartTimer{Task: task, Entry: models.TimeEntry{Start: now}},
+		fmt.Sprintf("Timer started on '%s'", task.Title)))
+	m.refreshData()
+	return m, tea.Batch(cmds...)
+}
+
+// updateStopTimer handles the note prompt shown when a timer is stopped
+func (m model) updateStopTimer(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.timerPrompt, cmd = m.timerPrompt.Update(msg)
+	if m.timerPrompt.Done() {
+		m.stopping.Note = m.timerPrompt.Value()
+		cmd = m.execute(m.stopping, "Timer stopped, "+formatDuration(m.stopping.End.Sub(m.stopping.Entry.Start))+" recorded")
+		m.stopping = nil
+		m.activeView = "tasks"
+		m.refreshData()
+	}
+	return m, cmd
+}
+
+// renderTimerHeader shows the running timer above every view
+func (m model) renderTimerHeader() string {
+	task, entry, ok := m.runningTimer()
+	if !ok {
+		return ""
+	}
+	projectName := ""
+	for _, p := range m.projectList.projects {
+		if p.ID == task.ProjectID {
+			projectName = p.Name
+		}
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true).
+		Render(fmt.Sprintf("⏱ %s  %s (%s)", formatClock(entry.Duration(time.Now())), task.Title, projectName)) + "\n\n"
+}
+
+// formatClock formats a duration as h:mm:ss for the running timer
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// formatDuration formats tracked time as hours and minutes, e.g. 2h 05m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
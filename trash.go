@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"freelancy.go/internal/history"
+	"freelancy.go/internal/models"
+)
+
+// TrashList holds the trashed projects and tasks shown in the trash view.
+// The cursor runs over projects first, then tasks
+type TrashList struct {
+	projects []models.Project
+	tasks    []models.Task
+	cursor   int
+}
+
+func (m *model) updateTrashList() {
+	m.trashList.projects, m.trashList.tasks = m.storage.GetTrash()
+	total := len(m.trashList.projects) + len(m.trashList.tasks)
+	if m.trashList.cursor >= total {
+		m.trashList.cursor = total - 1
+	}
+	if m.trashList.cursor < 0 {
+		m.trashList.cursor = 0
+	}
+}
+
+// updateTrash handles keys in the trash view
+func (m model) updateTrash(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	total := len(m.trashList.projects) + len(m.trashList.tasks)
+
+	switch msg.String() {
+	case "esc", "b":
+		m.activeView = "projects"
+		m.refreshData()
+	case "up":
+		m.trashList.cursor--
+		if m.trashList.cursor < 0 {
+			m.trashList.cursor = total - 1
+		}
+	case "down":
+		m.trashList.cursor++
+		if m.trashList.cursor >= total {
+			m.trashList.cursor = 0
+		}
+	case "r", "p":
+		if total == 0 {
+			return m, nil
+		}
+		restore := msg.String() == "r"
+
+		var change history.Command
+		var success, question string
+		if m.trashList.cursor < len(m.trashList.projects) {
+			project := m.trashList.projects[m.trashList.cursor]
+			if restore {
+				change, success = &history.RestoreProject{Project: project}, "Project restored"
+			} else {
+				change, success = &history.PurgeProject{Project: project}, "Project purged"
+				question = projectQuestion("Permanently delete", project)
+			}
+		} else {
+			task := m.trashList.tasks[m.trashList.cursor-len(m.trashList.projects)]
+			if restore {
+				change, success = &history.RestoreTask{Task: task}, "Task restored"
+			} else {
+				change, success = &history.PurgeTask{Task: task}, "Task purged"
+				question = fmt.Sprintf("Permanently delete task '%s'?", task.Title)
+			}
+		}
+
+		if question != "" {
+			m.confirm(question, change, success)
+			return m, nil
+		}
+		cmd = m.execute(change, success)
+		m.updateTrashList()
+	}
+
+	return m, cmd
+}
+
+func (m model) renderTrash() string {
+	s := "Trash (R: restore, P: purge permanently, U/Ctrl+R: undo/redo, ↑/↓: select, Shift+L: messages, ESC: back, Q: quit)\n\n"
+
+	if m.config.TrashRetentionDays > 0 {
+		s += fmt.Sprintf("Items are purged automatically %d days after deletion.\n\n", m.config.TrashRetentionDays)
+	}
+
+	if len(m.trashList.projects) == 0 && len(m.trashList.tasks) == 0 {
+		return s + "Trash is empty\n"
+	}
+
+	rowStyle := lipgloss.NewStyle().PaddingLeft(2)
+	selectedStyle := lipgloss.NewStyle().
+		PaddingLeft(2).
+		Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	row := func(i int, text string, deletedAt *time.Time) string {
+		style := rowStyle
+		if i == m.trashList.cursor {
+			style = selectedStyle
+		}
+		return style.Render(text) + "  " + dimStyle.Render(m.trashAge(deletedAt)) + "\n"
+	}
+
+	projectNames := make(map[int]string)
+	for _, p := range m.allProjects() {
+		projectNames[p.ID] = p.Name
+	}
+
+	if len(m.trashList.projects) > 0 {
+		s += "Projects\n"
+		for i, p := range m.trashList.projects {
+			s += row(i, fmt.Sprintf("%s (%s, %d tasks)", p.Name, p.Client, len(p.Tasks)), p.DeletedAt)
+		}
+		s += "\n"
+	}
+
+	if len(m.trashList.tasks) > 0 {
+		s += "Tasks\n"
+		for i, t := range m.trashList.tasks {
+			s += row(len(m.trashList.projects)+i, fmt.Sprintf("%s (%s)", t.Title, projectNames[t.ProjectID]), t.DeletedAt)
+		}
+	}
+
+	return s
+}
+
+// trashAge describes when an item was deleted and when it will be purged
+func (m model) trashAge(deletedAt *time.Time) string {
+	if deletedAt == nil {
+		return ""
+	}
+	text := "deleted " + deletedAt.Format("2006-01-02 15:04")
+	if m.config.TrashRetentionDays > 0 {
+		purgeAt := deletedAt.AddDate(0, 0, m.config.TrashRetentionDays)
+		days := int(time.Until(purgeAt).Hours()/24) + 1
+		text += fmt.Sprintf(", purged in %d days", days)
+	}
+	return text
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ClientForm asks for the name and contact details of a client
+type ClientForm struct {
+	title      string
+	inputs     []textinput.Model
+	focusIndex int
+	done       bool
+	validation validation
+}
+
+// NewClientForm returns an empty form for a new client
+func NewClientForm() ClientForm {
+	labels := []string{"Name      ", "Email     ", "Phone     ", "Address   ", "Currency  ", "Rate      ", "Notes     "}
+	placeholders := []string{
+		"Client name",
+		"billing@example.com (optional)",
+		"(optional)",
+		"Street, city, country (optional)",
+		"USD, EUR... (optional)",
+		"Default hourly rate of new projects (optional)",
+		"(optional)",
+	}
+
+	inputs := make([]textinput.Model, len(labels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = labels[i]
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[0].Focus()
+
+	return ClientForm{
+		title:  "New Client",
+		inputs: inputs,
+		validation: newValidation(Required("name"), ValidateEmail, nil, nil,
+			ValidateCurrency, ValidateRate, nil),
+	}
+}
+
+// EditClientForm returns a form pre-filled with the details of an existing client
+func EditClientForm(c Client) ClientForm {
+	m := NewClientForm()
+	m.title = "Edit Client"
+	m.inputs[0].SetValue(c.Name)
+	m.inputs[1].SetValue(c.Email)
+	m.inputs[2].SetValue(c.Phone)
+	m.inputs[3].SetValue(c.Address)
+	m.inputs[4].SetValue(c.Currency)
+	if c.Rate > 0 {
+		m.inputs[5].SetValue(strconv.FormatFloat(c.Rate, 'f', -1, 64))
+	}
+	m.inputs[6].SetValue(c.Notes)
+	return m
+}
+
+func (m ClientForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m ClientForm) Update(msg tea.Msg) (ClientForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch s := msg.String(); s {
+		case "tab", "shift+tab", "enter", "up", "down":
+			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				if invalid := m.validation.check(m.inputs); invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
+				m.done = true
+				return m, nil
+			}
+
+			if s == "up" || s == "shift+tab" {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+			if m.focusIndex >= len(m.inputs) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs) - 1
+			}
+			return m, m.setFocus(m.focusIndex)
+		}
+
+		m.validation.clear(m.focusIndex)
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// setFocus moves the cursor to the input at index
+func (m *ClientForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := range m.inputs {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m ClientForm) View() string {
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+	for i := range m.inputs {
+		b.WriteString(m.inputs[i].View())
+		b.WriteString(m.validation.render(i))
+		b.WriteRune('\n')
+	}
+
+	button := "[ Save ]"
+	if m.focusIndex == len(m.inputs)-1 {
+		button = "[ " + lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("Save") + " ]"
+	}
+	b.WriteString("\n" + button + "\n\n(Enter on the last field: save, ESC: cancel)\n")
+	return b.String()
+}
+
+func (m ClientForm) Done() bool {
+	return m.done
+}
+
+// GetValues returns the details entered, with the currency code in upper
+// case. The values have been validated once the form is done
+func (m ClientForm) GetValues() Client {
+	value := func(i int) string {
+		return strings.TrimSpace(m.inputs[i].Value())
+	}
+	rate, _ := ParseRate(value(5))
+	return Client{
+		Name:     value(0),
+		Email:    value(1),
+		Phone:    value(2),
+		Address:  value(3),
+		Currency: strings.ToUpper(value(4)),
+		Rate:     rate,
+		Notes:    value(6),
+	}
+}
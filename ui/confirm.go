@@ -0,0 +1,52 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Confirm is a modal yes/no question asked before a destructive action.
+// While it is open it takes every key press; only y confirms
+type Confirm struct {
+	question string
+	open     bool
+}
+
+func NewConfirm(question string) Confirm {
+	return Confirm{question: question, open: true}
+}
+
+// Open reports whether the dialog is still waiting for an answer
+func (c Confirm) Open() bool {
+	return c.open
+}
+
+// Update closes the dialog on y, n or esc and reports whether the action was
+// confirmed. Other keys are ignored so a stray key press cannot confirm
+func (c Confirm) Update(msg tea.Msg) (Confirm, bool) {
+	if !c.open {
+		return c, false
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y":
+			c.open = false
+			return c, true
+		case "n", "N", "esc":
+			c.open = false
+		}
+	}
+	return c, false
+}
+
+func (c Confirm) View() string {
+	if !c.open {
+		return ""
+	}
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("9")).
+		Padding(1, 2).
+		Render(c.question + "\n\n" + keyStyle.Render("[y]") + " Yes    " + keyStyle.Render("[n]") + " No")
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ExpenseForm asks for the project, date, amount, category, note and receipt
+// of an expense
+type ExpenseForm struct {
+	title      string
+	inputs     []textinput.Model
+	focusIndex int
+	done       bool
+	validation validation
+
+	// The project input is a picker over projects; project is the index of
+	// the chosen project or -1 for a general business expense
+	projects []Project
+	project  int
+	// currency is filled in when no project is chosen
+	currency string
+}
+
+// Indexes of the inputs of the expense form
+const (
+	expenseProjectInput = iota
+	expenseDateInput
+	expenseAmountInput
+	expenseCurrencyInput
+	expenseCategoryInput
+	expenseNoteInput
+	expenseReceiptInput
+)
+
+// NewExpenseForm returns a form for an expense made on date for the project
+// with projectID, or for the business when it is 0. The currency follows the
+// chosen project, and is currency for business expenses
+func NewExpenseForm(projects []Project, projectID int, date, currency string) ExpenseForm {
+	labels := []string{"Project   ", "Date      ", "Amount    ", "Currency  ", "Category  ", "Note      ", "Receipt   "}
+	placeholders := []string{
+		"Business expense (no project)",
+		"YYYY-MM-DD",
+		"49.90",
+		"USD, EUR...",
+		"Hosting, stock assets, subcontractor...",
+		"(optional)",
+		"Path of the receipt file (optional)",
+	}
+
+	inputs := make([]textinput.Model, len(labels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = labels[i]
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[expenseDateInput].SetValue(date)
+	inputs[expenseCurrencyInput].SetValue(currency)
+	inputs[0].Focus()
+
+	m := ExpenseForm{
+		title:  "New Expense",
+		inputs: inputs,
+		validation: newValidation(nil, ValidateDate("date"), ValidateAmount, ValidateCurrency,
+			Required("category"), nil, ValidateFile("receipt")),
+		projects: projects,
+		project:  -1,
+		currency: currency,
+	}
+	for i, p := range projects {
+		if p.ID == projectID {
+			m.pickProject(i)
+		}
+	}
+	return m
+}
+
+// EditExpenseForm returns a form pre-filled with the details of an existing
+// expense, falling back to currency like NewExpenseForm
+func EditExpenseForm(e Expense, projects []Project, currency string) ExpenseForm {
+	m := NewExpenseForm(projects, e.ProjectID, e.Date, currency)
+	m.title = "Edit Expense"
+	m.inputs[expenseAmountInput].SetValue(strconv.FormatFloat(e.Amount, 'f', -1, 64))
+	// Keep the currency of the expense even if its project's differs
+	m.inputs[expenseCurrencyInput].SetValue(e.Currency)
+	m.inputs[expenseCategoryInput].SetValue(e.Category)
+	m.inputs[expenseNoteInput].SetValue(e.Note)
+	m.inputs[expenseReceiptInput].SetValue(e.Receipt)
+	return m
+}
+
+func (m ExpenseForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m ExpenseForm) Update(msg tea.Msg) (ExpenseForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch s := msg.String(); s {
+		case "tab", "shift+tab", "enter", "up", "down":
+			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				if invalid := m.validation.check(m.inputs); invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
+				m.done = true
+				return m, nil
+			}
+
+			if s == "up" || s == "shift+tab" {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+			if m.focusIndex >= len(m.inputs) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs) - 1
+			}
+			return m, m.setFocus(m.focusIndex)
+		}
+
+		m.validation.clear(m.focusIndex)
+		if m.focusIndex == expenseProjectInput {
+			m.updatePicker(msg)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// setFocus moves the cursor to the input at index
+func (m *ExpenseForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := range m.inputs {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
+// updatePicker chooses a project with the arrow keys, or the next project
+// whose name starts with a typed letter
+func (m *ExpenseForm) updatePicker(msg tea.KeyMsg) {
+	if len(m.projects) == 0 {
+		return
+	}
+	switch msg.String() {
+	case "left":
+		// Going left from the first project makes it a business expense
+		if m.project < 0 {
+			m.pickProject(len(m.projects) - 1)
+		} else {
+			m.pickProject(m.project - 1)
+		}
+	case "right":
+		if m.project == len(m.projects)-1 {
+			m.pickProject(-1)
+		} else {
+			m.pickProject(m.project + 1)
+		}
+	case "backspace", "delete":
+		m.pickProject(-1)
+	default:
+		if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+			return
+		}
+		letter := strings.ToLower(string(msg.Runes))
+		for n := 1; n <= len(m.projects); n++ {
+			i := (m.project + n) % len(m.projects)
+			if strings.HasPrefix(strings.ToLower(m.projects[i].Name), letter) {
+				m.pickProject(i)
+				return
+			}
+		}
+	}
+}
+
+// pickProject chooses the project at index, or none for -1. The currency
+// follows the project unless it was changed by hand
+func (m *ExpenseForm) pickProject(index int) {
+	current := strings.TrimSpace(m.inputs[expenseCurrencyInput].Value())
+	followsProject := current == "" || strings.EqualFold(current, m.projectCurrency())
+
+	m.project = index
+	if index < 0 {
+		m.inputs[expenseProjectInput].SetValue("")
+	} else {
+		m.inputs[expenseProjectInput].SetValue(m.projects[index].Name)
+	}
+	if followsProject {
+		m.inputs[expenseCurrencyInput].SetValue(m.projectCurrency())
+	}
+}
+
+// projectCurrency returns the currency of the chosen project, or the form's
+// currency for business expenses
+func (m ExpenseForm) projectCurrency() string {
+	if m.project >= 0 && m.projects[m.project].Currency != "" {
+		return m.projects[m.project].Currency
+	}
+	return m.currency
+}
+
+func (m ExpenseForm) View() string {
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+	for i := range m.inputs {
+		b.WriteString(m.inputs[i].View())
+		if i == expenseProjectInput && m.focusIndex == expenseProjectInput && len(m.projects) > 0 {
+			b.WriteString(hintStyle.Render("  ←/→ or first letter: choose project"))
+		}
+		b.WriteString(m.validation.render(i))
+		b.WriteRune('\n')
+	}
+
+	button := "[ Save ]"
+	if m.focusIndex == len(m.inputs)-1 {
+		button = "[ " + lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("Save") + " ]"
+	}
+	b.WriteString("\n" + button + "\n\n(Enter on the last field: save, ESC: cancel)\n")
+	return b.String()
+}
+
+func (m ExpenseForm) Done() bool {
+	return m.done
+}
+
+// GetValues returns the details entered, with the currency code in upper
+// case and that of the project when it was left empty. The values have been
+// validated once the form is done
+func (m ExpenseForm) GetValues() Expense {
+	value := func(i int) string {
+		return strings.TrimSpace(m.inputs[i].Value())
+	}
+	amount, _ := ParseAmount(value(expenseAmountInput))
+	e := Expense{
+		Date:     value(expenseDateInput),
+		Amount:   amount,
+		Currency: strings.ToUpper(value(expenseCurrencyInput)),
+		Category: value(expenseCategoryInput),
+		Note:     value(expenseNoteInput),
+		Receipt:  value(expenseReceiptInput),
+	}
+	if e.Currency == "" {
+		e.Currency = m.projectCurrency()
+	}
+	if m.project >= 0 {
+		e.ProjectID = m.projects[m.project].ID
+	}
+	return e
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,24 +10,155 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-type MonthIncome struct {
-	Month    string
+// PeriodIncome is the income and expenses of a week, month, quarter or year
+type PeriodIncome struct {
+	Period   string    // "Week of Jan 5 2026", "Jan 2026", "Q1 2026" or "2026"
+	Start    time.Time // first day of the period
+	End      time.Time // first day of the next period
 	Income   float64
+	Expenses float64
+	// Forecast is the income active projects are expected to earn in the
+	// period, on top of Income
+	Forecast float64
 	Projects []string
 }
 
+// Net returns the income of the period minus its expenses
+func (pi PeriodIncome) Net() float64 {
+	return pi.Income - pi.Expenses
+}
+
+// ProjectIncome is the income and expenses of a project over the periods of
+// the chart. The general business expenses have no project name
+type ProjectIncome struct {
+	Name     string
+	Income   float64
+	Expenses float64
+	Forecast float64
+}
+
+// Net returns the income of the project minus its expenses
+func (pi ProjectIncome) Net() float64 {
+	return pi.Income - pi.Expenses
+}
+
+// Granularity is the length of the periods the chart adds income up by
+type Granularity int
+
+const (
+	Weekly Granularity = iota
+	Monthly
+	Quarterly
+	Yearly
+)
+
+var granularityNames = []string{"Weekly", "Monthly", "Quarterly", "Yearly"}
+
+func (g Granularity) String() string {
+	return strings.ToLower(granularityNames[g])
+}
+
+// start returns the first day of the period date falls in. Weeks start on
+// Monday
+func (g Granularity) start(date time.Time) time.Time {
+	year, month, day := date.Date()
+	switch g {
+	case Weekly:
+		return time.Date(year, month, day-(int(date.Weekday())+6)%7, 0, 0, 0, 0, date.Location())
+	case Quarterly:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, date.Location())
+	case Yearly:
+		return time.Date(year, 1, 1, 0, 0, 0, 0, date.Location())
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+}
+
+// add moves the start of a period n periods later, or earlier when n is
+// negative
+func (g Granularity) add(start time.Time, n int) time.Time {
+	switch g {
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Quarterly:
+		return start.AddDate(0, 3*n, 0)
+	case Yearly:
+		return start.AddDate(n, 0, 0)
+	}
+	return start.AddDate(0, n, 0)
+}
+
+// label names the period starting at start in full
+func (g Granularity) label(start time.Time) string {
+	switch g {
+	case Weekly:
+		return "Week of " + start.Format("Jan 2 2006")
+	case Quarterly:
+		return fmt.Sprintf("Q%d %d", (int(start.Month())-1)/3+1, start.Year())
+	case Yearly:
+		return start.Format("2006")
+	}
+	return start.Format("Jan 2006")
+}
+
+// short names the period starting at start under its bar
+func (g Granularity) short(start time.Time) string {
+	switch g {
+	case Weekly:
+		return start.Format("Jan 2")
+	case Quarterly:
+		return fmt.Sprintf("Q%d '%s", (int(start.Month())-1)/3+1, start.Format("06"))
+	case Yearly:
+		return start.Format("2006")
+	}
+	return start.Format("Jan '06")
+}
+
+// Limits of the chart layout. Without a window size the terminal is taken to
+// be defaultWidth wide; the box and the axis take up chromeWidth of it
+const (
+	minPeriods   = 3
+	maxBarWidth  = 6
+	defaultWidth = 80
+	chromeWidth  = 12
+)
+
 type IncomeChart struct {
-	monthlyIncomes []MonthIncome
-	maxIncome     float64
-	graphHeight   int
-	style         lipgloss.Style
-	selected      int
+	periods     []PeriodIncome
+	maxIncome   float64
+	graphHeight int
+	style       lipgloss.Style
+	selected    int
+	// The chart shows count periods of granularity, ending offset periods
+	// before the current one (after it when offset is negative). With a
+	// forecast the window reaches further ahead to show it
+	granularity Granularity
+	count       int
+	offset      int
+	// width is the width of the terminal, 0 until it is known
+	width int
+	// cash shows payments received instead of income earned
+	cash bool
+	// forecast adds the income expected from active projects, weighted by
+	// the share of their tasks done when weighted is set
+	forecast bool
+	weighted bool
+	projects []Project
+	// expenses are the general business expenses, not spent on a project
+	expenses []Earning
+	totals   []ProjectIncome
+	// money formats amounts in the reporting currency
+	money func(amount float64) string
 }
 
-func NewIncomeChart() IncomeChart {
+// NewIncomeChart returns an empty chart that formats amounts with money
+func NewIncomeChart(money func(amount float64) string) IncomeChart {
 	return IncomeChart{
-		monthlyIncomes: make([]MonthIncome, 12),
-		graphHeight:    15,
+		money:       money,
+		periods:     make([]PeriodIncome, 12),
+		graphHeight: 15,
+		granularity: Monthly,
+		count:       12,
+		forecast:    true,
 		style: lipgloss.NewStyle().
 			BorderStyle(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("63")).
@@ -35,50 +167,194 @@ func NewIncomeChart() IncomeChart {
 	}
 }
 
-func (ic *IncomeChart) UpdateData(projects []Project) {
-	// Получаем текущую дату
-	now := time.Now()
+// UpdateData fills the chart with the income and expenses of projects and
+// the general business expenses
+func (ic *IncomeChart) UpdateData(projects []Project, expenses []Earning) {
+	ic.projects = projects
+	ic.expenses = expenses
+	ic.refresh()
+}
+
+// refresh adds up the income and expenses again for the window of the chart
+func (ic *IncomeChart) refresh() {
+	ahead := 0
+	if ic.forecast {
+		ahead = max(1, ic.count/4)
+	}
+	last := ic.granularity.add(ic.granularity.start(time.Now()), ahead-ic.offset)
+	ic.periods, ic.totals = ic.aggregate(ic.granularity, ic.granularity.add(last, 1-ic.count), ic.count, ic.forecast)
+	if ic.selected >= ic.count {
+		ic.selected = -1
+	}
+
+	// Bars are as high as the income or the expenses of the period, whichever is larger
+	ic.maxIncome = 0
+	for _, pi := range ic.periods {
+		ic.maxIncome = max(ic.maxIncome, pi.Income+pi.Forecast, pi.Expenses)
+	}
+}
 
-	// Инициализируем массив месяцев
-	for i := 0; i < 12; i++ {
-		date := now.AddDate(0, -(11-i), 0)
-		ic.monthlyIncomes[i] = MonthIncome{
-			Month:    date.Format("Jan 2006"),
-			Income:   0,
+// aggregate adds up the income and expenses of the projects and the general
+// business expenses over count periods of granularity g from start, with the
+// forecast income when forecast is set, and returns the totals of each
+// project over them
+func (ic IncomeChart) aggregate(g Granularity, start time.Time, count int, forecast bool) ([]PeriodIncome, []ProjectIncome) {
+	periods := make([]PeriodIncome, count)
+	for i := range periods {
+		from := g.add(start, i)
+		periods[i] = PeriodIncome{
+			Period:   g.label(from),
+			Start:    from,
+			End:      g.add(start, i+1),
 			Projects: make([]string, 0),
 		}
 	}
 
-	for _, project := range projects {
-		if project.Status != "Completed" {
-			continue
+	// Income is attributed to the period in which it was earned, or paid
+	// when showing cash received. Expenses count in the period they were made
+	// and forecast income in the period it is expected to be earned in
+	var totals []ProjectIncome
+	for _, project := range ic.projects {
+		earnings := project.Earnings
+		if ic.cash {
+			earnings = project.Receipts
+		}
+		earned := byPeriod(periods, earnings)
+		spent := byPeriod(periods, project.Expenses)
+		projected := make([]float64, len(periods))
+		if forecast {
+			projected = byPeriod(periods, project.Forecast)
+		}
+		// Hourly and daily projects are forecast from their pace, which
+		// already follows how the work goes
+		weight := 1.0
+		if ic.weighted && project.Billing != BillingHourly && project.Billing != BillingDaily {
+			weight = project.Completion
 		}
 
-		deadline, err := time.Parse("2006-01-02", project.Deadline)
-		if err != nil {
+		total := ProjectIncome{Name: project.Name}
+		for i := range earned {
+			projected[i] *= weight
+			if earned[i] == 0 && spent[i] == 0 && projected[i] == 0 {
+				continue
+			}
+			periods[i].Income += earned[i]
+			periods[i].Expenses += spent[i]
+			periods[i].Forecast += projected[i]
+			var parts []string
+			if earned[i] != 0 || projected[i] == 0 {
+				parts = append(parts, ic.money(earned[i]))
+			}
+			if spent[i] > 0 {
+				parts = append(parts, "expenses "+ic.money(spent[i]))
+			}
+			if projected[i] > 0 {
+				parts = append(parts, "forecast "+ic.money(projected[i]))
+			}
+			periods[i].Projects = append(periods[i].Projects,
+				fmt.Sprintf("%s (%s)", project.Name, strings.Join(parts, ", ")))
+			total.Income += earned[i]
+			total.Expenses += spent[i]
+			total.Forecast += projected[i]
+		}
+		if total.Income != 0 || total.Expenses != 0 || total.Forecast != 0 {
+			totals = append(totals, total)
+		}
+	}
+
+	general := ProjectIncome{}
+	for i, amount := range byPeriod(periods, ic.expenses) {
+		if amount == 0 {
 			continue
 		}
+		periods[i].Expenses += amount
+		periods[i].Projects = append(periods[i].Projects,
+			fmt.Sprintf("Business expenses (%s)", ic.money(amount)))
+		general.Expenses += amount
+	}
+	if general.Expenses != 0 {
+		totals = append(totals, general)
+	}
+	return periods, totals
+}
 
-		deadlineStr := deadline.Format("Jan 2006")
-		for i, monthIncome := range ic.monthlyIncomes {
-			if monthIncome.Month == deadlineStr {
-				ic.monthlyIncomes[i].Income += project.Cost
-				ic.monthlyIncomes[i].Projects = append(
-					ic.monthlyIncomes[i].Projects,
-					fmt.Sprintf("%s ($%.2f)", project.Name, project.Cost),
-				)
+// byPeriod adds up amounts by the period they fall in
+func byPeriod(periods []PeriodIncome, amounts []Earning) []float64 {
+	sums := make([]float64, len(periods))
+	for _, a := range amounts {
+		for i, pi := range periods {
+			if !a.Date.Before(pi.Start) && a.Date.Before(pi.End) {
+				sums[i] += a.Amount
 				break
 			}
 		}
 	}
+	return sums
+}
 
-	// Находим максимальный доход
-	ic.maxIncome = 0
-	for _, mi := range ic.monthlyIncomes {
-		if mi.Income > ic.maxIncome {
-			ic.maxIncome = mi.Income
+// Basis describes what the chart shows: "earned" or "cash received"
+func (ic IncomeChart) Basis() string {
+	if ic.cash {
+		return "cash received"
+	}
+	return "earned"
+}
+
+// Cash reports whether the chart shows payments received rather than income
+// earned
+func (ic IncomeChart) Cash() bool {
+	return ic.cash
+}
+
+// MonthsSince returns the income of each month from the one start falls in
+// up to the current month, oldest first, whatever periods the chart shows.
+// Forecast income is left out
+func (ic IncomeChart) MonthsSince(start time.Time) []PeriodIncome {
+	first, current := Monthly.start(start), Monthly.start(time.Now())
+	count := (current.Year()-first.Year())*12 + int(current.Month()-first.Month()) + 1
+	if count <= 0 {
+		return nil
+	}
+	months, _ := ic.aggregate(Monthly, first, count, false)
+	return months
+}
+
+// Granularity returns the length of the periods the chart shows
+func (ic IncomeChart) Granularity() Granularity {
+	return ic.granularity
+}
+
+// Periods returns the income of each period shown, oldest first
+func (ic IncomeChart) Periods() []PeriodIncome {
+	return append([]PeriodIncome(nil), ic.periods...)
+}
+
+// Realised returns the income of each period shown, oldest first, without
+// the forecast income
+func (ic IncomeChart) Realised() []PeriodIncome {
+	if len(ic.periods) == 0 {
+		return nil
+	}
+	periods, _ := ic.aggregate(ic.granularity, ic.periods[0].Start, len(ic.periods), false)
+	return periods
+}
+
+// Projects returns the income and expenses of each project over the periods
+// shown, followed by the general business expenses
+func (ic IncomeChart) Projects() []ProjectIncome {
+	return append([]ProjectIncome(nil), ic.totals...)
+}
+
+// notForecast returns the names of the projects still earning that are
+// missing from the forecast
+func (ic IncomeChart) notForecast() []string {
+	var names []string
+	for _, p := range ic.projects {
+		if p.NotForecast {
+			names = append(names, p.Name)
 		}
 	}
+	return names
 }
 
 func (ic IncomeChart) Update(msg tea.Msg) (IncomeChart, tea.Cmd) {
@@ -89,22 +365,118 @@ func (ic IncomeChart) Update(msg tea.Msg) (IncomeChart, tea.Cmd) {
 			if ic.selected > 0 {
 				ic.selected--
 			} else if ic.selected == -1 {
-				ic.selected = 11
+				ic.selected = ic.count - 1
 			}
 		case "right":
-			if ic.selected < 11 {
+			if ic.selected < ic.count-1 {
 				ic.selected++
 			}
 		case "esc":
 			ic.selected = -1
+		case "c":
+			ic.cash = !ic.cash
+			ic.refresh()
+		case "f":
+			ic.forecast = !ic.forecast
+			ic.refresh()
+		case "w":
+			ic.weighted = !ic.weighted
+			ic.refresh()
+		case "g":
+			// A new granularity starts again from the current period
+			ic.granularity = (ic.granularity + 1) % Granularity(len(granularityNames))
+			ic.offset = 0
+			ic.selected = -1
+			ic.refresh()
+		case "[":
+			ic.offset++
+			ic.refresh()
+		case "]":
+			ic.offset--
+			ic.refresh()
+		case "+", "=":
+			ic.count = max(minPeriods, ic.count/2)
+			ic.refresh()
+		case "-":
+			ic.count = min(ic.maxPeriods(), ic.count*2)
+			ic.refresh()
+		}
+	case tea.WindowSizeMsg:
+		ic.width = msg.Width
+		if ic.count > ic.maxPeriods() {
+			ic.count = ic.maxPeriods()
+			ic.refresh()
 		}
 	}
 	return ic, nil
 }
 
+// hints lists the keys of the chart, wrapped to fit the box
+func (ic IncomeChart) hints() string {
+	keys := []string{"← →: select", "ESC: deselect", "G: week/month/quarter/year", "[ ]: earlier/later",
+		"+ -: zoom", "C: earned/cash",
+		"F: forecast", "W: weight forecast by tasks done", "T: taxes", "X: export PDF", "Q: quit"}
+	width := ic.chartWidth() + chromeWidth - 4
+	line := "(" + keys[0]
+	var lines []string
+	for _, key := range keys[1:] {
+		if lipgloss.Width(line)+lipgloss.Width(key)+3 > width {
+			lines = append(lines, line+",")
+			line = " " + key
+			continue
+		}
+		line += ", " + key
+	}
+	return strings.Join(append(lines, line+")"), "\n")
+}
+
+// axisLabel writes an amount on the y axis in the six columns it has,
+// abbreviating millions and more as 1.2M, 3.4B or 5.6T
+func axisLabel(amount int) string {
+	if amount < 1000000 {
+		return strconv.Itoa(amount)
+	}
+	value := float64(amount) / 1e6
+	for _, unit := range []string{"M", "B", "T"} {
+		if label := fmt.Sprintf("%.1f%s", value, unit); len(label) <= 6 || unit == "T" {
+			return label
+		}
+		value /= 1000
+	}
+	return ""
+}
+
+// chartWidth returns the number of columns the bars can take up
+func (ic IncomeChart) chartWidth() int {
+	if ic.width == 0 {
+		return defaultWidth - chromeWidth
+	}
+	return ic.width - chromeWidth
+}
+
+// maxPeriods returns how many periods fit in the chart with bars one column
+// wide and a column between them
+func (ic IncomeChart) maxPeriods() int {
+	return max(minPeriods, ic.chartWidth()/2)
+}
+
+// barWidth returns how wide the bars are drawn to fill the chart
+func (ic IncomeChart) barWidth() int {
+	return max(1, min(maxBarWidth, ic.chartWidth()/ic.count-1))
+}
+
 func (ic IncomeChart) View() string {
 	var s strings.Builder
-	s.WriteString("Income Chart (← →: select month, ESC: deselect, Q: quit)\n\n")
+	title := fmt.Sprintf("Income Chart, %s, %s, %s to %s", ic.Basis(), ic.granularity,
+		ic.periods[0].Period, ic.periods[len(ic.periods)-1].Period)
+	switch {
+	case ic.forecast && ic.weighted:
+		title += ", forecast weighted by tasks done"
+	case ic.forecast:
+		title += ", with forecast"
+	}
+	s.WriteString(title + "\n")
+	s.WriteString(ic.hints() + "\n\n")
 
 	// Создаем график
 	heightMultiplier := float64(ic.graphHeight) / ic.maxIncome
@@ -112,79 +484,159 @@ func (ic IncomeChart) View() string {
 		heightMultiplier = 0
 	}
 
+	barWidth := ic.barWidth()
+	bar := strings.Repeat("█", barWidth)
 	graph := make([][]string, ic.graphHeight)
 	for i := range graph {
-		graph[i] = make([]string, 12)
+		graph[i] = make([]string, ic.count)
 		for j := range graph[i] {
-			graph[i][j] = " "
+			graph[i][j] = strings.Repeat(" ", barWidth)
 		}
 	}
 
 	// Заполняем столбцы
+	// The part of a bar covered by the expenses of the period is drawn in
+	// red, so what is left in blue is the net profit. Forecast income is
+	// stacked on top in a lighter shade
 	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
-	selectedStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("39")).
-		Background(lipgloss.Color("236"))
+	expenseStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("167"))
+	forecastStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("117"))
+	forecastBar := strings.Repeat("░", barWidth)
 
-	for month := 0; month < 12; month++ {
-		income := ic.monthlyIncomes[month].Income
-		height := int(income * heightMultiplier)
-		style := barStyle
-		if month == ic.selected {
-			style = selectedStyle
-		}
-		for i := 0; i < height; i++ {
-			graph[ic.graphHeight-1-i][month] = style.Render("█")
+	for period := 0; period < ic.count; period++ {
+		pi := ic.periods[period]
+		height := int(pi.Income * heightMultiplier)
+		forecastHeight := int((pi.Income + pi.Forecast) * heightMultiplier)
+		expenseHeight := int(pi.Expenses * heightMultiplier)
+		for i := 0; i < max(forecastHeight, expenseHeight); i++ {
+			style, cell := barStyle, bar
+			if i < expenseHeight {
+				style = expenseStyle
+			} else if i >= height {
+				style, cell = forecastStyle, forecastBar
+			}
+			if period == ic.selected {
+				style = style.Background(lipgloss.Color("236"))
+			}
+			graph[ic.graphHeight-1-i][period] = style.Render(cell)
 		}
 	}
 
 	// Отрисовываем график
 	for i := 0; i < ic.graphHeight; i++ {
 		value := int(float64(ic.graphHeight-i) * ic.maxIncome / float64(ic.graphHeight))
-		s.WriteString(fmt.Sprintf("%6d │", value))
-		
-		for j := 0; j < 12; j++ {
+		s.WriteString(fmt.Sprintf("%6s │", axisLabel(value)))
+
+		for j := 0; j < ic.count; j++ {
 			s.WriteString(graph[i][j] + " ")
 		}
 		s.WriteString("\n")
 	}
 
 	// Добавляем ось X
-	s.WriteString("       └" + strings.Repeat("─", 24) + "\n")
+	// The axis is dashed under the periods that have not started yet
+	slot := barWidth + 1
+	now := time.Now()
+	s.WriteString("       └")
+	for _, pi := range ic.periods {
+		if pi.Start.After(now) {
+			s.WriteString(strings.Repeat("╌", slot))
+		} else {
+			s.WriteString(strings.Repeat("─", slot))
+		}
+	}
+	s.WriteString("\n")
 
 	// Добавляем подписи месяцев
+	// Labels that are wider than a bar go under every few bars only
 	s.WriteString("        ")
 	monthStyle := lipgloss.NewStyle()
 	selectedMonthStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
 
-	for i, mi := range ic.monthlyIncomes {
+	every := 1
+	for _, pi := range ic.periods {
+		every = max(every, (len(ic.granularity.short(pi.Start))+slot)/slot)
+	}
+	for i := 0; i < ic.count; i += every {
 		style := monthStyle
-		if i == ic.selected {
+		if i <= ic.selected && ic.selected < i+every {
 			style = selectedMonthStyle
 		}
-		s.WriteString(style.Render(fmt.Sprintf("%-2s", mi.Month[:2])))
+		width := min(every, ic.count-i) * slot
+		label := ic.granularity.short(ic.periods[i].Start)
+		if len(label) > width {
+			label = label[:width]
+		}
+		s.WriteString(style.Render(fmt.Sprintf("%-*s", width, label)))
+	}
+	s.WriteString("\n        " + barStyle.Render("█") + " net profit  " + expenseStyle.Render("█") + " expenses")
+	if ic.forecast {
+		s.WriteString("  " + forecastStyle.Render("░") + " forecast")
+	}
+	s.WriteString("\n")
+	if names := ic.notForecast(); ic.forecast && len(names) > 0 {
+		s.WriteString("        Not forecast, no deadline ahead or no work in four weeks: " + strings.Join(names, ", ") + "\n")
 	}
-	s.WriteString("\n\n")
+	s.WriteString("\n")
 
 	// Показываем детальную информацию о выбранном месяце
 	if ic.selected >= 0 {
-		mi := ic.monthlyIncomes[ic.selected]
-		s.WriteString(fmt.Sprintf("%s: $%.2f\n", mi.Month, mi.Income))
-		if len(mi.Projects) > 0 {
+		pi := ic.periods[ic.selected]
+		s.WriteString(fmt.Sprintf("%s: %s, expenses %s, net profit %s",
+			pi.Period, ic.money(pi.Income), ic.money(pi.Expenses), ic.money(pi.Net())))
+		if pi.Forecast > 0 {
+			s.WriteString(", forecast " + ic.money(pi.Forecast))
+		}
+		s.WriteString("\n")
+		if len(pi.Projects) > 0 {
 			s.WriteString("Projects:\n")
-			for _, proj := range mi.Projects {
+			for _, proj := range pi.Projects {
 				s.WriteString(fmt.Sprintf("  • %s\n", proj))
 			}
 		}
 	} else {
 		// Показываем общую статистику
-		totalIncome := 0.0
-		for _, mi := range ic.monthlyIncomes {
-			totalIncome += mi.Income
+		// Averages are over the periods that have started
+		totalIncome, totalExpenses, totalForecast := 0.0, 0.0, 0.0
+		started := 0
+		for _, pi := range ic.periods {
+			totalIncome += pi.Income
+			totalExpenses += pi.Expenses
+			totalForecast += pi.Forecast
+			if !pi.Start.After(now) {
+				started++
+			}
+		}
+		started = max(started, 1)
+		s.WriteString(fmt.Sprintf("Total Income: %s\n", ic.money(totalIncome)))
+		s.WriteString(fmt.Sprintf("Total Expenses: %s\n", ic.money(totalExpenses)))
+		s.WriteString(fmt.Sprintf("Net Profit: %s\n", ic.money(totalIncome-totalExpenses)))
+		per := granularityNames[ic.granularity]
+		s.WriteString(fmt.Sprintf("Average %s Income: %s\n", per, ic.money(totalIncome/float64(started))))
+		s.WriteString(fmt.Sprintf("Average %s Net Profit: %s\n", per, ic.money((totalIncome-totalExpenses)/float64(started))))
+		if totalForecast > 0 {
+			s.WriteString(fmt.Sprintf("Forecast Income: %s\n", ic.money(totalForecast)))
+		}
+
+		if len(ic.totals) > 0 {
+			// The forecast column is left out when there is nothing to forecast
+			columns := 4
+			if totalForecast > 0 {
+				columns = 5
+			}
+			row := "%-24.24s" + strings.Repeat(" %12s", columns-1) + "\n"
+			header := []any{"Project", "Income", "Expenses", "Net profit", "Forecast"}
+			s.WriteString("\n" + fmt.Sprintf(row, header[:columns]...))
+			for _, pi := range ic.totals {
+				name := pi.Name
+				if name == "" {
+					name = "Business expenses"
+				}
+				values := []any{name, ic.money(pi.Income), ic.money(pi.Expenses), ic.money(pi.Net()), ic.money(pi.Forecast)}
+				s.WriteString(fmt.Sprintf(row, values[:columns]...))
+			}
 		}
-		s.WriteString(fmt.Sprintf("Total Income: $%.2f\n", totalIncome))
-		s.WriteString(fmt.Sprintf("Average Monthly Income: $%.2f\n", totalIncome/12))
 	}
 
 	return ic.style.Render(s.String())
-} 
\ No newline at end of file
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// InvoiceForm asks for the dates, tax rate and output directory of a new
+// invoice. Every field starts out with a default
+type InvoiceForm struct {
+	title      string
+	inputs     []textinput.Model
+	focusIndex int
+	done       bool
+	validation validation
+}
+
+func NewInvoiceForm(title, issueDate, dueDate string, taxRate float64, dir string) InvoiceForm {
+	labels := []string{"Issue date  ", "Due date    ", "Tax rate %  ", "Directory   "}
+	values := []string{issueDate, dueDate, fmt.Sprint(taxRate), dir}
+	placeholders := []string{"YYYY-MM-DD", "YYYY-MM-DD", "0", "Where to write the invoice files"}
+
+	inputs := make([]textinput.Model, len(labels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = labels[i]
+		inputs[i].Placeholder = placeholders[i]
+		inputs[i].SetValue(values[i])
+	}
+	inputs[0].Focus()
+
+	return InvoiceForm{
+		title:      title,
+		inputs:     inputs,
+		validation: newValidation(ValidateDate("issue date"), ValidateDate("due date"), ValidateTaxRate, Required("directory")),
+	}
+}
+
+func (m InvoiceForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m InvoiceForm) Update(msg tea.Msg) (InvoiceForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch s := msg.String(); s {
+		case "tab", "shift+tab", "enter", "up", "down":
+			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				invalid := m.validation.check(m.inputs)
+				if invalid < 0 && m.dueBeforeIssue() {
+					m.validation.errs[1] = errors.New("due date must not be before the issue date")
+					invalid = 1
+				}
+				if invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
+				m.done = true
+				return m, nil
+			}
+
+			if s == "up" || s == "shift+tab" {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+			if m.focusIndex >= len(m.inputs) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs) - 1
+			}
+			return m, m.setFocus(m.focusIndex)
+		}
+
+		m.validation.clear(m.focusIndex)
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// dueBeforeIssue reports whether the due date is earlier than the issue date.
+// Both dates are validated first, so comparing the strings is enough
+func (m InvoiceForm) dueBeforeIssue() bool {
+	issue, due, _, _ := m.GetValues()
+	return due < issue
+}
+
+// setFocus moves the cursor to the input at index
+func (m *InvoiceForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := range m.inputs {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m InvoiceForm) View() string {
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+	for i := range m.inputs {
+		b.WriteString(m.inputs[i].View())
+		b.WriteString(m.validation.render(i))
+		b.WriteRune('\n')
+	}
+
+	button := "[ Issue invoice ]"
+	if m.focusIndex == len(m.inputs)-1 {
+		button = "[ " + lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("Issue invoice") + " ]"
+	}
+	b.WriteString("\n" + button + "\n\n(Enter on the last field: issue, ESC: back)\n")
+	return b.String()
+}
+
+func (m InvoiceForm) Done() bool {
+	return m.done
+}
+
+// GetValues returns the issue date, due date, tax rate and directory. The
+// values have been validated once the form is done
+func (m InvoiceForm) GetValues() (string, string, float64, string) {
+	taxRate, _ := ParseTaxRate(m.inputs[2].Value())
+	return strings.TrimSpace(m.inputs[0].Value()),
+		strings.TrimSpace(m.inputs[1].Value()),
+		taxRate,
+		strings.TrimSpace(m.inputs[3].Value())
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PaymentForm asks for the date, amount and method of a payment received
+type PaymentForm struct {
+	title      string
+	inputs     []textinput.Model
+	focusIndex int
+	done       bool
+	validation validation
+}
+
+// NewPaymentForm returns a form for a payment made on date, suggesting amount
+// when it is positive
+func NewPaymentForm(title, date string, amount float64) PaymentForm {
+	labels := []string{"Date    ", "Amount  ", "Method  "}
+	placeholders := []string{"YYYY-MM-DD", "1500.00", "Bank transfer, card, cash... (optional)"}
+
+	inputs := make([]textinput.Model, len(labels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = labels[i]
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[0].SetValue(date)
+	if amount > 0 {
+		inputs[1].SetValue(fmt.Sprintf("%.2f", amount))
+	}
+	inputs[0].Focus()
+
+	return PaymentForm{
+		title:      title,
+		inputs:     inputs,
+		validation: newValidation(ValidateDate("date"), ValidateAmount, nil),
+	}
+}
+
+func (m PaymentForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m PaymentForm) Update(msg tea.Msg) (PaymentForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch s := msg.String(); s {
+		case "tab", "shift+tab", "enter", "up", "down":
+			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				if invalid := m.validation.check(m.inputs); invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
+				m.done = true
+				return m, nil
+			}
+
+			if s == "up" || s == "shift+tab" {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+			if m.focusIndex >= len(m.inputs) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs) - 1
+			}
+			return m, m.setFocus(m.focusIndex)
+		}
+
+		m.validation.clear(m.focusIndex)
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// setFocus moves the cursor to the input at index
+func (m *PaymentForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := range m.inputs {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m PaymentForm) View() string {
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+	for i := range m.inputs {
+		b.WriteString(m.inputs[i].View())
+		b.WriteString(m.validation.render(i))
+		b.WriteRune('\n')
+	}
+
+	button := "[ Record payment ]"
+	if m.focusIndex == len(m.inputs)-1 {
+		button = "[ " + lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render("Record payment") + " ]"
+	}
+	b.WriteString("\n" + button + "\n\n(Enter on the last field: save, ESC: cancel)\n")
+	return b.String()
+}
+
+func (m PaymentForm) Done() bool {
+	return m.done
+}
+
+// GetValues returns the date, amount and method. The values have been
+// validated once the form is done
+func (m PaymentForm) GetValues() (string, float64, string) {
+	amount, _ := ParseAmount(m.inputs[1].Value())
+	return strings.TrimSpace(m.inputs[0].Value()), amount, strings.TrimSpace(m.inputs[2].Value())
+}
@@ -1,14 +1,62 @@
 package ui
 
+import "time"
+
 // Project represents a project in the UI layer
 type Project struct {
 	ID       int
 	Name     string
 	Client   string
+	ClientID int
 	Cost     float64
+	Billing  string
+	Rate     float64
+	Currency string
 	Deadline string
 	Status   string
 	Tasks    []Task
+	// Earnings, Receipts, the payments received by the day they were paid,
+	// and Expenses are in the reporting currency of the income chart
+	Earnings []Earning
+	Receipts []Earning
+	Expenses []Earning
+	// Forecast is the income an active project is expected to earn, and
+	// Completion the share of its tasks done that the forecast of a fixed
+	// price project can be weighted by. NotForecast marks hourly and daily
+	// projects still earning that no forecast could be made for
+	Forecast    []Earning
+	Completion  float64
+	NotForecast bool
+}
+
+// Client represents a client in the UI layer
+type Client struct {
+	ID       int
+	Name     string
+	Email    string
+	Phone    string
+	Address  string
+	Currency string
+	Rate     float64
+	Notes    string
+}
+
+// Expense represents an expense in the UI layer
+type Expense struct {
+	ID        int
+	Date      string
+	Amount    float64
+	Currency  string
+	Category  string
+	Note      string
+	Receipt   string
+	ProjectID int
+}
+
+// Earning is income from a project attributed to the day it was earned
+type Earning struct {
+	Date   time.Time
+	Amount float64
 }
 
 // Task represents a task in the UI layer
@@ -17,5 +65,6 @@ type Task struct {
 	ProjectID   int
 	Title       string
 	Description string
+	Deadline    string
 	Status      string
-} 
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -9,37 +10,100 @@ import (
 )
 
 type ProjectForm struct {
+	title      string
 	inputs     []textinput.Model
 	focusIndex int
 	done       bool
+	validation validation
+
+	// The client input is a picker over clients; client is the index of the
+	// chosen client or -1 for none
+	clients []Client
+	client  int
+	// currency is filled in when no client is chosen
+	currency string
 }
 
-func NewProjectForm() ProjectForm {
-	inputs := make([]textinput.Model, 4)
+// clientInput is the index of the client picker among the inputs
+const clientInput = 1
+
+// currencyInput is the index of the currency among the inputs
+const currencyInput = 4
+
+// hintStyle dims the key hints shown next to an input
+var hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// NewProjectForm returns an empty form for a new project done for one of
+// clients, billed in currency unless the chosen client has its own
+func NewProjectForm(clients []Client, currency string) ProjectForm {
+	inputs := make([]textinput.Model, 6)
 	
 	// Name input
 	inputs[0] = textinput.New()
 	inputs[0].Placeholder = "Project Name"
 	inputs[0].Focus()
 	
-	// Client input
+	// Client picker
 	inputs[1] = textinput.New()
-	inputs[1].Placeholder = "Client Name"
+	inputs[1].Placeholder = "No client"
+	if len(clients) == 0 {
+		inputs[1].Placeholder = "No client (add clients with Shift+C in the projects view)"
+	}
 	
-	// Cost input
+	// Billing mode input
 	inputs[2] = textinput.New()
-	inputs[2].Placeholder = "Cost"
+	inputs[2].Placeholder = "Billing: fixed, hourly or daily (default fixed)"
 	
-	// Deadline input
+	// Cost input
 	inputs[3] = textinput.New()
-	inputs[3].Placeholder = "Deadline (YYYY-MM-DD)"
+	inputs[3].Placeholder = "Cost, or rate per hour or day"
+	
+	// Currency input
+	inputs[4] = textinput.New()
+	inputs[4].Placeholder = "Currency (USD, EUR...)"
+	inputs[4].SetValue(currency)
+	
+	// Deadline input
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = "Deadline (YYYY-MM-DD)"
 	
 	return ProjectForm{
+		title:      "Create New Project",
 		inputs:     inputs,
 		focusIndex: 0,
+		validation: newValidation(Required("name"), nil, ValidateBilling, ValidateCost,
+			ValidateCurrency, ValidateDeadline(true)),
+		clients:  clients,
+		client:   -1,
+		currency: currency,
 	}
 }
 
+// EditProjectForm returns a form pre-filled with the details of an existing
+// project, falling back to currency like NewProjectForm
+func EditProjectForm(p Project, clients []Client, currency string) ProjectForm {
+	m := NewProjectForm(clients, currency)
+	m.title = "Edit Project"
+	m.inputs[0].SetValue(p.Name)
+	for i, c := range clients {
+		if c.ID == p.ClientID {
+			m.pickClient(i)
+		}
+	}
+	// Keep the currency of the project even if its client's differs
+	m.inputs[currencyInput].SetValue(p.Currency)
+	m.inputs[2].SetValue(p.Billing)
+	amount := p.Cost
+	if p.Billing == BillingHourly || p.Billing == BillingDaily {
+		amount = p.Rate
+	}
+	m.inputs[3].SetValue(strconv.FormatFloat(amount, 'f', -1, 64))
+	m.inputs[5].SetValue(p.Deadline)
+	// The deadline may have passed since it was set; keep it without asking
+	m.validation.confirmedDeadline = p.Deadline
+	return m
+}
+
 func (m ProjectForm) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -55,6 +119,9 @@ func (m ProjectForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s := msg.String()
 			
 			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				if invalid := m.validation.check(m.inputs); invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
 				m.done = true
 				return m, nil
 			}
@@ -70,16 +137,17 @@ func (m ProjectForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if m.focusIndex < 0 {
 				m.focusIndex = len(m.inputs) - 1
 			}
-			
-			cmds := make([]tea.Cmd, len(m.inputs))
-			for i := 0; i < len(m.inputs); i++ {
-				if i == m.focusIndex {
-					cmds[i] = m.inputs[i].Focus()
-					continue
-				}
-				m.inputs[i].Blur()
+			if m.focusIndex == 3 {
+				m.suggestRate()
 			}
-			return m, tea.Batch(cmds...)
+			
+			return m, m.setFocus(m.focusIndex)
+		}
+		
+		m.validation.clear(m.focusIndex)
+		if m.focusIndex == clientInput {
+			m.updatePicker(msg)
+			return m, nil
 		}
 	}
 	
@@ -87,6 +155,108 @@ func (m ProjectForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// setFocus moves the cursor to the input at index
+func (m *ProjectForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := 0; i < len(m.inputs); i++ {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
+// updatePicker chooses a client with the arrow keys, or the next client whose
+// name starts with a typed letter
+func (m *ProjectForm) updatePicker(msg tea.KeyMsg) {
+	if len(m.clients) == 0 {
+		return
+	}
+	switch msg.String() {
+	case "left":
+		// Going left from the first client leaves the project without one
+		if m.client < 0 {
+			m.pickClient(len(m.clients) - 1)
+		} else {
+			m.pickClient(m.client - 1)
+		}
+	case "right":
+		if m.client == len(m.clients)-1 {
+			m.pickClient(-1)
+		} else {
+			m.pickClient(m.client + 1)
+		}
+	case "backspace", "delete":
+		m.pickClient(-1)
+	default:
+		if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+			return
+		}
+		letter := strings.ToLower(string(msg.Runes))
+		for n := 1; n <= len(m.clients); n++ {
+			i := (m.client + n) % len(m.clients)
+			if strings.HasPrefix(strings.ToLower(m.clients[i].Name), letter) {
+				m.pickClient(i)
+				return
+			}
+		}
+	}
+}
+
+// pickClient chooses the client at index, or no client for -1. The currency
+// follows the client unless it was changed by hand
+func (m *ProjectForm) pickClient(index int) {
+	current := strings.TrimSpace(m.inputs[currencyInput].Value())
+	followsClient := current == "" || strings.EqualFold(current, m.clientCurrency())
+
+	m.client = index
+	if index < 0 {
+		m.inputs[clientInput].SetValue("")
+	} else {
+		m.inputs[clientInput].SetValue(m.clients[index].Name)
+	}
+	if followsClient {
+		m.inputs[currencyInput].SetValue(m.clientCurrency())
+	}
+}
+
+// clientCurrency returns the currency of the chosen client, or the form's
+// currency when there is no client or the client has none
+func (m ProjectForm) clientCurrency() string {
+	if m.client >= 0 && m.clients[m.client].Currency != "" {
+		return m.clients[m.client].Currency
+	}
+	return m.currency
+}
+
+// suggestRate fills in the default rate of the chosen client when an hourly
+// project has no rate yet
+func (m *ProjectForm) suggestRate() {
+	billing, _ := ParseBilling(m.inputs[2].Value())
+	if m.client < 0 || billing != BillingHourly || strings.TrimSpace(m.inputs[3].Value()) != "" {
+		return
+	}
+	if rate := m.clients[m.client].Rate; rate > 0 {
+		m.inputs[3].SetValue(strconv.FormatFloat(rate, 'f', -1, 64))
+	}
+}
+
+// Currency returns the currency code entered, in upper case
+func (m ProjectForm) Currency() string {
+	return strings.ToUpper(strings.TrimSpace(m.inputs[currencyInput].Value()))
+}
+
+// ClientID returns the ID of the chosen client, or 0 for none
+func (m ProjectForm) ClientID() int {
+	if m.client < 0 {
+		return 0
+	}
+	return m.clients[m.client].ID
+}
+
 func (m *ProjectForm) updateInputs(msg tea.Msg) tea.Cmd {
 	var cmds = make([]tea.Cmd, len(m.inputs))
 	
@@ -100,10 +270,14 @@ func (m *ProjectForm) updateInputs(msg tea.Msg) tea.Cmd {
 func (m ProjectForm) View() string {
 	var b strings.Builder
 	
-	b.WriteString("Create New Project\n\n")
+	b.WriteString(m.title + "\n\n")
 	
 	for i := range m.inputs {
 		b.WriteString(m.inputs[i].View())
+		if i == clientInput && m.focusIndex == clientInput && len(m.clients) > 0 {
+			b.WriteString(hintStyle.Render("  ←/→ or first letter: choose client"))
+		}
+		b.WriteString(m.validation.render(i))
 		if i < len(m.inputs)-1 {
 			b.WriteRune('\n')
 		}
@@ -122,9 +296,12 @@ func (m ProjectForm) Done() bool {
 	return m.done
 }
 
-func (m ProjectForm) GetValues() (string, string, string, string) {
-	return m.inputs[0].Value(),
-		m.inputs[1].Value(),
-		m.inputs[2].Value(),
-		m.inputs[3].Value()
+// GetValues returns the name, client name, billing mode, cost or rate and
+// deadline entered
+func (m ProjectForm) GetValues() (string, string, string, string, string) {
+	return strings.TrimSpace(m.inputs[0].Value()),
+		strings.TrimSpace(m.inputs[1].Value()),
+		strings.TrimSpace(m.inputs[2].Value()),
+		strings.TrimSpace(m.inputs[3].Value()),
+		strings.TrimSpace(m.inputs[5].Value())
 } 
\ No newline at end of file
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Prompt asks for a single line of text, submitted with Enter
+type Prompt struct {
+	title string
+	input textinput.Model
+	done  bool
+}
+
+func NewPrompt(title, placeholder string) Prompt {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.Focus()
+	return Prompt{title: title, input: input}
+}
+
+func (m Prompt) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m Prompt) Update(msg tea.Msg) (Prompt, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "enter" {
+		m.done = true
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Prompt) View() string {
+	return m.title + "\n\n" + m.input.View() + "\n\n(Enter: save, ESC: cancel)\n"
+}
+
+func (m Prompt) Done() bool {
+	return m.done
+}
+
+func (m Prompt) Value() string {
+	return strings.TrimSpace(m.input.Value())
+}
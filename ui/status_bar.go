@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toastDuration is how long success and info messages stay in the status bar
+const toastDuration = 3 * time.Second
+
+// MessageLevel tells how a status message is shown
+type MessageLevel int
+
+const (
+	LevelInfo MessageLevel = iota
+	LevelSuccess
+	LevelWarning
+	LevelError
+)
+
+// Message is an entry of the session message log
+type Message struct {
+	Time  time.Time
+	Level MessageLevel
+	Text  string
+}
+
+// Sticky reports whether the message stays in the status bar until it is
+// replaced, rather than disappearing after a few seconds
+func (msg Message) Sticky() bool {
+	return msg.Level == LevelWarning || msg.Level == LevelError
+}
+
+// toastExpiredMsg clears a transient message once its time is up
+type toastExpiredMsg struct {
+	id int
+}
+
+// StatusBar is the footer line showing the outcome of the last action. It
+// also keeps every message of the session for the message log
+type StatusBar struct {
+	log     []Message
+	current int // index into log of the message shown, -1 when empty
+	id      int // incremented for every message so stale expiries are ignored
+}
+
+func NewStatusBar() StatusBar {
+	return StatusBar{current: -1}
+}
+
+// Info shows a transient message
+func (s *StatusBar) Info(text string) tea.Cmd {
+	return s.push(LevelInfo, text)
+}
+
+// Success shows a transient message confirming an action
+func (s *StatusBar) Success(text string) tea.Cmd {
+	return s.push(LevelSuccess, text)
+}
+
+// Warning shows a message that stays until the next one
+func (s *StatusBar) Warning(text string) tea.Cmd {
+	return s.push(LevelWarning, text)
+}
+
+// Error shows a message that stays until the next one
+func (s *StatusBar) Error(text string) tea.Cmd {
+	return s.push(LevelError, text)
+}
+
+func (s *StatusBar) push(level MessageLevel, text string) tea.Cmd {
+	msg := Message{Time: time.Now(), Level: level, Text: text}
+	s.log = append(s.log, msg)
+	s.current = len(s.log) - 1
+	s.id++
+
+	if msg.Sticky() {
+		return nil
+	}
+	id := s.id
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// Update clears transient messages when they expire
+func (s StatusBar) Update(msg tea.Msg) StatusBar {
+	if msg, ok := msg.(toastExpiredMsg); ok && msg.id == s.id {
+		s.current = -1
+	}
+	return s
+}
+
+// Messages returns every message of the session, oldest first
+func (s StatusBar) Messages() []Message {
+	return s.log
+}
+
+func (s StatusBar) View() string {
+	if s.current < 0 {
+		return ""
+	}
+	return RenderMessage(s.log[s.current])
+}
+
+// RenderMessage formats a message with the icon and colour of its level
+func RenderMessage(msg Message) string {
+	switch msg.Level {
+	case LevelSuccess:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("✓ " + msg.Text)
+	case LevelWarning:
+		return warningStyle.Render("! " + msg.Text)
+	case LevelError:
+		return errorStyle.Render("✗ " + msg.Text)
+	default:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("• " + msg.Text)
+	}
+}
@@ -9,9 +9,11 @@ import (
 )
 
 type TaskForm struct {
+	title      string
 	inputs     []textinput.Model
 	focusIndex int
 	done       bool
+	validation validation
 	projectID  int
 }
 
@@ -32,12 +34,26 @@ func NewTaskForm(projectID int) TaskForm {
 	inputs[2].Placeholder = "Deadline (YYYY-MM-DD)"
 	
 	return TaskForm{
+		title:      "Create New Task",
 		inputs:     inputs,
 		focusIndex: 0,
+		validation: newValidation(Required("title"), nil, ValidateDeadline(false)),
 		projectID:  projectID,
 	}
 }
 
+// EditTaskForm returns a form pre-filled with the details of an existing task
+func EditTaskForm(t Task) TaskForm {
+	m := NewTaskForm(t.ProjectID)
+	m.title = "Edit Task"
+	m.inputs[0].SetValue(t.Title)
+	m.inputs[1].SetValue(t.Description)
+	m.inputs[2].SetValue(t.Deadline)
+	// The deadline may have passed since it was set; keep it without asking
+	m.validation.confirmedDeadline = t.Deadline
+	return m
+}
+
 func (m TaskForm) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -53,6 +69,9 @@ func (m TaskForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s := msg.String()
 			
 			if s == "enter" && m.focusIndex == len(m.inputs)-1 {
+				if invalid := m.validation.check(m.inputs); invalid >= 0 {
+					return m, m.setFocus(invalid)
+				}
 				m.done = true
 				return m, nil
 			}
@@ -69,22 +88,30 @@ func (m TaskForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.focusIndex = len(m.inputs) - 1
 			}
 			
-			cmds := make([]tea.Cmd, len(m.inputs))
-			for i := 0; i < len(m.inputs); i++ {
-				if i == m.focusIndex {
-					cmds[i] = m.inputs[i].Focus()
-					continue
-				}
-				m.inputs[i].Blur()
-			}
-			return m, tea.Batch(cmds...)
+			return m, m.setFocus(m.focusIndex)
 		}
+		
+		m.validation.clear(m.focusIndex)
 	}
 	
 	cmd := m.updateInputs(msg)
 	return m, cmd
 }
 
+// setFocus moves the cursor to the input at index
+func (m *TaskForm) setFocus(index int) tea.Cmd {
+	m.focusIndex = index
+	cmds := make([]tea.Cmd, len(m.inputs))
+	for i := 0; i < len(m.inputs); i++ {
+		if i == m.focusIndex {
+			cmds[i] = m.inputs[i].Focus()
+			continue
+		}
+		m.inputs[i].Blur()
+	}
+	return tea.Batch(cmds...)
+}
+
 func (m *TaskForm) updateInputs(msg tea.Msg) tea.Cmd {
 	var cmds = make([]tea.Cmd, len(m.inputs))
 	
@@ -98,10 +125,11 @@ func (m *TaskForm) updateInputs(msg tea.Msg) tea.Cmd {
 func (m TaskForm) View() string {
 	var b strings.Builder
 	
-	b.WriteString("Create New Task\n\n")
+	b.WriteString(m.title + "\n\n")
 	
 	for i := range m.inputs {
 		b.WriteString(m.inputs[i].View())
+		b.WriteString(m.validation.render(i))
 		if i < len(m.inputs)-1 {
 			b.WriteRune('\n')
 		}
@@ -121,9 +149,9 @@ func (m TaskForm) Done() bool {
 }
 
 func (m TaskForm) GetValues() (string, string, string) {
-	return m.inputs[0].Value(),
-		m.inputs[1].Value(),
-		m.inputs[2].Value()
+	return strings.TrimSpace(m.inputs[0].Value()),
+		strings.TrimSpace(m.inputs[1].Value()),
+		strings.TrimSpace(m.inputs[2].Value())
 }
 
 func (m TaskForm) GetProjectID() int {
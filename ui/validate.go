@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DateLayout is the format of deadlines entered in forms
+const DateLayout = "2006-01-02"
+
+// Validator checks the value of a form field
+type Validator func(value string) error
+
+// errPastDeadline is returned for deadlines before today. Unlike other
+// validation errors the user can confirm it by submitting the form again
+var errPastDeadline = errors.New("deadline is in the past, press Enter again to keep it")
+
+// costPattern matches bare amounts with optional thousands separators and at
+// most two decimal places. The currency of an amount is never typed with it
+var costPattern = regexp.MustCompile(`^(\d+|\d{1,3}([, ]\d{3})+)(\.\d{1,2})?$`)
+
+// currencyMarkPattern matches currency signs and codes such as $, € or EUR
+var currencyMarkPattern = regexp.MustCompile(`\p{Sc}|\pL`)
+
+// emailPattern matches addresses of the form name@domain.tld
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// currencyPattern matches three letter ISO 4217 currency codes
+var currencyPattern = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+var (
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+)
+
+// Required rejects empty values
+func Required(field string) Validator {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		return nil
+	}
+}
+
+// ValidateCost accepts positive amounts such as 1500, 1,500.50 or 1 500
+func ValidateCost(value string) error {
+	_, err := ParseCost(value)
+	return err
+}
+
+// ParseCost converts a cost entered in a form into a number
+func ParseCost(value string) (float64, error) {
+	return parseAmount("cost", value)
+}
+
+// ValidateAmount accepts positive amounts of money in the same formats as ValidateCost
+func ValidateAmount(value string) error {
+	_, err := ParseAmount(value)
+	return err
+}
+
+// ParseAmount converts an amount of money entered in a form into a number
+func ParseAmount(value string) (float64, error) {
+	return parseAmount("amount", value)
+}
+
+// parseAmount converts a positive amount of money, naming field in errors
+func parseAmount(field, value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("%s is required", field)
+	}
+	if currencyMarkPattern.MatchString(value) {
+		return 0, fmt.Errorf("%s must be a number without a currency, which is set separately", field)
+	}
+	if !costPattern.MatchString(value) {
+		return 0, fmt.Errorf("%s must be a number such as 1500 or 1,500.50", field)
+	}
+	value = strings.NewReplacer(",", "", " ", "").Replace(value)
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("%s is too large", field)
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("%s must be greater than zero", field)
+	}
+	return amount, nil
+}
+
+// ValidateRate accepts an optional hourly rate in the same formats as ValidateCost
+func ValidateRate(value string) error {
+	_, err := ParseRate(value)
+	return err
+}
+
+// ParseRate converts an hourly rate entered in a form into a number. Empty
+// values mean no rate
+func ParseRate(value string) (float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	return parseAmount("rate", value)
+}
+
+// ValidateEmail accepts empty values and addresses such as name@example.com
+func ValidateEmail(value string) error {
+	if value = strings.TrimSpace(value); value != "" && !emailPattern.MatchString(value) {
+		return errors.New("email must be an address such as name@example.com")
+	}
+	return nil
+}
+
+// ValidateCurrency accepts empty values and three letter currency codes such as USD
+func ValidateCurrency(value string) error {
+	if value = strings.TrimSpace(value); value != "" && !currencyPattern.MatchString(value) {
+		return errors.New("currency must be a three letter code such as USD or EUR")
+	}
+	return nil
+}
+
+// Billing modes accepted by ParseBilling
+const (
+	BillingFixed  = "fixed"
+	BillingHourly = "hourly"
+	BillingDaily  = "daily"
+)
+
+// ValidateBilling accepts the billing modes fixed, hourly and daily
+func ValidateBilling(value string) error {
+	_, err := ParseBilling(value)
+	return err
+}
+
+// ParseBilling converts a billing mode entered in a form, defaulting to fixed
+func ParseBilling(value string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case "":
+		return BillingFixed, nil
+	case BillingFixed, BillingHourly, BillingDaily:
+		return mode, nil
+	}
+	return "", errors.New("billing must be fixed, hourly or daily")
+}
+
+// ValidateDeadline accepts real calendar dates in YYYY-MM-DD format that are
+// not before today. Empty values are rejected only when required is set
+func ValidateDeadline(required bool) Validator {
+	return func(value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			if required {
+				return errors.New("deadline is required")
+			}
+			return nil
+		}
+		deadline, err := time.ParseInLocation(DateLayout, value, time.Local)
+		if err != nil {
+			return errors.New("deadline must be a real date in YYYY-MM-DD format")
+		}
+		now := time.Now()
+		if deadline.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)) {
+			return errPastDeadline
+		}
+		return nil
+	}
+}
+
+// ValidateDate accepts real calendar dates in YYYY-MM-DD format
+func ValidateDate(field string) Validator {
+	return func(value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if _, err := time.ParseInLocation(DateLayout, value, time.Local); err != nil {
+			return fmt.Errorf("%s must be a real date in YYYY-MM-DD format", field)
+		}
+		return nil
+	}
+}
+
+// ValidateFile accepts empty values and paths of existing files
+func ValidateFile(field string) Validator {
+	return func(value string) error {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil
+		}
+		info, err := os.Stat(value)
+		if err != nil {
+			return fmt.Errorf("%s file not found", field)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s must be a file, not a directory", field)
+		}
+		return nil
+	}
+}
+
+// ValidateTaxRate accepts percentages from 0 to 100 such as 20 or 7.5%
+func ValidateTaxRate(value string) error {
+	_, err := ParseTaxRate(value)
+	return err
+}
+
+// ParseTaxRate converts a tax rate entered in a form, with or without a
+// percent sign, into a number. Empty values mean no tax
+func ParseTaxRate(value string) (float64, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "%")
+	if value == "" {
+		return 0, nil
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return 0, errors.New("tax rate must be a percentage between 0 and 100")
+	}
+	return percent, nil
+}
+
+// validation holds the error shown under each input of a form
+type validation struct {
+	validators []Validator
+	errs       []error
+	// confirmedDeadline is a past deadline the user has already been warned
+	// about; submitting it again is accepted
+	confirmedDeadline string
+}
+
+func newValidation(validators ...Validator) validation {
+	return validation{
+		validators: validators,
+		errs:       make([]error, len(validators)),
+	}
+}
+
+// check validates every input and returns the index of the first invalid
+// one, or -1 when the form can be submitted
+func (v *validation) check(inputs []textinput.Model) int {
+	first := -1
+	for i, input := range inputs {
+		v.errs[i] = nil
+		if v.validators[i] == nil {
+			continue
+		}
+		value := strings.TrimSpace(input.Value())
+		err := v.validators[i](value)
+		if err == errPastDeadline {
+			if value == v.confirmedDeadline {
+				continue
+			}
+			v.confirmedDeadline = value
+		}
+		if err != nil {
+			v.errs[i] = err
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	return first
+}
+
+// clear removes the error shown under an input once it is edited
+func (v *validation) clear(i int) {
+	if i >= 0 && i < len(v.errs) {
+		v.errs[i] = nil
+	}
+}
+
+// render returns the message to show under an input, if any
+func (v validation) render(i int) string {
+	switch err := v.errs[i]; {
+	case err == nil:
+		return ""
+	case err == errPastDeadline:
+		return "\n" + warningStyle.Render("  ! "+err.Error())
+	default:
+		return "\n" + errorStyle.Render("  ✗ "+err.Error())
+	}
+}